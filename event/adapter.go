@@ -0,0 +1,231 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// adapterBuffer is the buffer size of the channel between a feed and an adapter's
+// helper goroutine.
+const adapterBuffer = 16
+
+// adapterSub is the Subscription returned by Map, Batch, Debounce, Throttle and
+// SubscribeHandler. It subscribes an internal channel to the feed and runs helper
+// goroutines which consume the values received there.
+//
+// Values still buffered when Unsubscribe is called are discarded.
+type adapterSub struct {
+	inner    Subscription
+	quit     chan struct{}
+	quitOnce sync.Once
+	failOnce sync.Once
+	wg       sync.WaitGroup
+	errOnce  sync.Once
+	err      chan error
+}
+
+// newAdapter subscribes to feed and starts run. run must return when sub.quit is
+// closed.
+func newAdapter[T any](feed *FeedOf[T], opts []SubscribeOption, run func(sub *adapterSub, in <-chan T)) *adapterSub {
+	in := make(chan T, adapterBuffer)
+	sub := &adapterSub{
+		inner: feed.Subscribe(in, opts...),
+		quit:  make(chan struct{}),
+		err:   make(chan error, 1),
+	}
+	sub.wg.Add(2)
+	go func() {
+		defer sub.wg.Done()
+		run(sub, in)
+	}()
+	go func() {
+		// Forward failures of the inner subscription and stop the helper.
+		closed := false
+		select {
+		case err, ok := <-sub.inner.Err():
+			if ok {
+				sub.fail(err)
+			} else {
+				closed = true
+			}
+		case <-sub.quit:
+		}
+		sub.wg.Done()
+		if closed {
+			// The feed was closed, end the subscription as if unsubscribed.
+			sub.Unsubscribe()
+		}
+	}()
+	return sub
+}
+
+func (sub *adapterSub) stop() {
+	sub.quitOnce.Do(func() { close(sub.quit) })
+}
+
+// fail ends the subscription, reporting err on the error channel. It must only be
+// called from the helper goroutines.
+func (sub *adapterSub) fail(err error) {
+	sub.failOnce.Do(func() {
+		sub.err <- err
+		sub.inner.Unsubscribe()
+		sub.stop()
+	})
+}
+
+// Unsubscribe cancels the subscription and waits for the helper goroutines to exit.
+func (sub *adapterSub) Unsubscribe() {
+	sub.errOnce.Do(func() {
+		sub.inner.Unsubscribe()
+		sub.stop()
+		sub.wg.Wait()
+		close(sub.err)
+	})
+}
+
+func (sub *adapterSub) Err() <-chan error {
+	return sub.err
+}
+
+// Map subscribes channel to feed, delivering fn(v) for every value v sent on the feed.
+// The options apply to the subscription on feed.
+func Map[T, U any](feed *FeedOf[T], fn func(T) U, channel chan<- U, opts ...SubscribeOption) Subscription {
+	return newAdapter(feed, opts, func(sub *adapterSub, in <-chan T) {
+		for {
+			select {
+			case v := <-in:
+				select {
+				case channel <- fn(v):
+				case <-sub.quit:
+					return
+				}
+			case <-sub.quit:
+				return
+			}
+		}
+	})
+}
+
+// Batch subscribes channel to feed, delivering values in batches of n. A partial batch
+// is delivered once maxWait has passed since its first value, unless maxWait is zero.
+func Batch[T any](feed *FeedOf[T], n int, maxWait time.Duration, channel chan<- []T, opts ...SubscribeOption) Subscription {
+	return newAdapter(feed, opts, func(sub *adapterSub, in <-chan T) {
+		var (
+			batch   []T
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timeout = nil
+			}
+			out := batch
+			batch = nil
+			select {
+			case channel <- out:
+				return true
+			case <-sub.quit:
+				return false
+			}
+		}
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case v := <-in:
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					timeout = timer.C
+				}
+				if len(batch) >= n && !flush() {
+					return
+				}
+			case <-timeout:
+				if !flush() {
+					return
+				}
+			case <-sub.quit:
+				return
+			}
+		}
+	})
+}
+
+// Debounce subscribes channel to feed, delivering a value only once no other value
+// has been sent on the feed for the duration d.
+func Debounce[T any](feed *FeedOf[T], d time.Duration, channel chan<- T, opts ...SubscribeOption) Subscription {
+	return newAdapter(feed, opts, func(sub *adapterSub, in <-chan T) {
+		timer := time.NewTimer(d)
+		timer.Stop()
+		defer timer.Stop()
+		var pending T
+		for {
+			select {
+			case pending = <-in:
+				timer.Reset(d)
+			case <-timer.C:
+				select {
+				case channel <- pending:
+				case <-sub.quit:
+					return
+				}
+			case <-sub.quit:
+				return
+			}
+		}
+	})
+}
+
+// Throttle subscribes channel to feed, delivering at most one value per period d. The
+// first value is delivered immediately. Of the values sent during the following
+// period, only the latest is delivered when the period ends.
+func Throttle[T any](feed *FeedOf[T], d time.Duration, channel chan<- T, opts ...SubscribeOption) Subscription {
+	return newAdapter(feed, opts, func(sub *adapterSub, in <-chan T) {
+		timer := time.NewTimer(d)
+		timer.Stop()
+		defer timer.Stop()
+		var (
+			open    = true // no value was delivered during the current period
+			pending T
+			has     bool
+		)
+		send := func(v T) bool {
+			select {
+			case channel <- v:
+				timer.Reset(d)
+				open = false
+				return true
+			case <-sub.quit:
+				return false
+			}
+		}
+		for {
+			select {
+			case v := <-in:
+				if open {
+					if !send(v) {
+						return
+					}
+				} else {
+					pending, has = v, true
+				}
+			case <-timer.C:
+				if has {
+					has = false
+					if !send(pending) {
+						return
+					}
+				} else {
+					open = true
+				}
+			case <-sub.quit:
+				return
+			}
+		}
+	})
+}
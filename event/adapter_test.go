@@ -0,0 +1,145 @@
+package event
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func expectRecv[T comparable](t *testing.T, ch <-chan T, want T) {
+	t.Helper()
+	select {
+	case v := <-ch:
+		if v != want {
+			t.Errorf("received %v, want %v", v, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for %v", want)
+	}
+}
+
+func expectNoRecv[T any](t *testing.T, ch <-chan T, wait time.Duration) {
+	t.Helper()
+	select {
+	case v := <-ch:
+		t.Errorf("unexpected value %v", v)
+	case <-time.After(wait):
+	}
+}
+
+func TestMap(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		ch   = make(chan string)
+		sub  = Map(&feed, strconv.Itoa, ch)
+	)
+	defer sub.Unsubscribe()
+	for i := 0; i < 3; i++ {
+		feed.Send(i)
+	}
+	for i := 0; i < 3; i++ {
+		expectRecv(t, ch, strconv.Itoa(i))
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		ch   = make(chan []int, 10)
+		sub  = Batch(&feed, 3, 50*time.Millisecond, ch)
+	)
+	defer sub.Unsubscribe()
+	for i := 0; i < 4; i++ {
+		feed.Send(i)
+	}
+	if b := <-ch; len(b) != 3 || b[0] != 0 || b[2] != 2 {
+		t.Errorf("first batch %v, want [0 1 2]", b)
+	}
+	// The partial batch is flushed after maxWait.
+	select {
+	case b := <-ch:
+		if len(b) != 1 || b[0] != 3 {
+			t.Errorf("second batch %v, want [3]", b)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("partial batch not flushed")
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		ch   = make(chan int, 10)
+		sub  = Debounce(&feed, 30*time.Millisecond, ch)
+	)
+	defer sub.Unsubscribe()
+	for i := 1; i <= 5; i++ {
+		feed.Send(i)
+	}
+	expectRecv(t, ch, 5)
+	expectNoRecv(t, ch, 60*time.Millisecond)
+}
+
+func TestThrottle(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		ch   = make(chan int, 10)
+		sub  = Throttle(&feed, 50*time.Millisecond, ch)
+	)
+	defer sub.Unsubscribe()
+	for i := 1; i <= 5; i++ {
+		feed.Send(i)
+	}
+	expectRecv(t, ch, 1)
+	expectRecv(t, ch, 5)
+	expectNoRecv(t, ch, 100*time.Millisecond)
+}
+
+// Checks that Unsubscribe stops the helper goroutines even if the adapter is blocked
+// delivering to a channel nobody reads.
+func TestAdapterUnsubscribe(t *testing.T) {
+	before := runtime.NumGoroutine()
+	var feed FeedOf[int]
+	subs := []Subscription{
+		Map(&feed, func(v int) int { return v }, make(chan int)),
+		Batch(&feed, 1, 0, make(chan []int)),
+		Debounce(&feed, time.Millisecond, make(chan int)),
+		Throttle(&feed, time.Millisecond, make(chan int)),
+	}
+	for i := 0; i < 3; i++ {
+		feed.Send(i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	for _, sub := range subs {
+		sub.Unsubscribe()
+		if _, ok := <-sub.Err(); ok {
+			t.Errorf("error channel not closed after unsubscribe")
+		}
+	}
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= before })
+	if nsent := feed.Send(99); nsent != 0 {
+		t.Errorf("send after unsubscribe delivered %d times", nsent)
+	}
+}
+
+func TestAdapterErr(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		ch   = make(chan int)
+		sub  = Map(&feed, func(v int) int { return v }, ch, WithPolicy(Disconnect))
+	)
+	defer sub.Unsubscribe()
+	// The adapter's internal buffer fills up while nobody reads ch.
+	for i := 0; i < adapterBuffer+2; i++ {
+		feed.Send(i)
+	}
+	select {
+	case err := <-sub.Err():
+		if err != ErrSlowSubscriber {
+			t.Errorf("got error %v, want %v", err, ErrSlowSubscriber)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("inner subscription error not forwarded")
+	}
+}
@@ -0,0 +1,117 @@
+package event
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrFeedClosed is returned when publishing to a feed which has been closed.
+	ErrFeedClosed = errors.New("event: feed closed")
+	// ErrQueueFull is returned by AsyncFeed.Publish when the queue is full and the
+	// overflow policy is OverflowError.
+	ErrQueueFull = errors.New("event: feed queue full")
+)
+
+// Sender is implemented by the feeds AsyncFeed can dispatch to: FeedOf[T], and Feed
+// with T = interface{}.
+type Sender[T any] interface {
+	Send(value T) (nsent int)
+}
+
+// OverflowPolicy decides what AsyncFeed.Publish does when the queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until the queue has room.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop discards the value, counting it in Dropped.
+	OverflowDrop
+	// OverflowError discards the value and returns ErrQueueFull.
+	OverflowError
+)
+
+// AsyncFeed decouples publishers from the subscribers of a feed. Publish puts values
+// into a bounded queue and a dispatcher goroutine sends them on the feed in order, so
+// slow subscribers only delay the dispatcher.
+//
+// Subscriptions are made on the wrapped feed.
+type AsyncFeed[T any] struct {
+	feed     Sender[T]
+	overflow OverflowPolicy
+	queue    chan T
+	done     chan struct{} // closed when the dispatcher exits
+	dropped  atomic.Uint64
+
+	// mu is held for reading by Publish, so that Close cannot close the queue while
+	// a value is being enqueued.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAsyncFeed starts dispatching values published on the returned feed to feed. size
+// is the capacity of the queue.
+func NewAsyncFeed[T any](feed Sender[T], size int, overflow OverflowPolicy) *AsyncFeed[T] {
+	a := &AsyncFeed[T]{
+		feed:     feed,
+		overflow: overflow,
+		queue:    make(chan T, size),
+		done:     make(chan struct{}),
+	}
+	go a.dispatch()
+	return a
+}
+
+func (a *AsyncFeed[T]) dispatch() {
+	defer close(a.done)
+	for value := range a.queue {
+		a.feed.Send(value)
+	}
+}
+
+// Publish queues value for delivery. What happens when the queue is full depends on
+// the overflow policy. It returns ErrFeedClosed after Close.
+func (a *AsyncFeed[T]) Publish(value T) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrFeedClosed
+	}
+	if a.overflow == OverflowBlock {
+		a.queue <- value
+		return nil
+	}
+	select {
+	case a.queue <- value:
+		return nil
+	default:
+		a.dropped.Add(1)
+		if a.overflow == OverflowError {
+			return ErrQueueFull
+		}
+		return nil
+	}
+}
+
+// Len returns the number of values waiting in the queue.
+func (a *AsyncFeed[T]) Len() int {
+	return len(a.queue)
+}
+
+// Dropped returns the number of values discarded because the queue was full.
+func (a *AsyncFeed[T]) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Close stops accepting values and waits until the queued values have been sent on
+// the feed.
+func (a *AsyncFeed[T]) Close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	<-a.done
+}
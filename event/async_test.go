@@ -0,0 +1,94 @@
+package event
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAsyncFeedPublish(t *testing.T) {
+	var (
+		feed  FeedOf[int]
+		ch    = make(chan int)
+		sub   = feed.Subscribe(ch)
+		async = NewAsyncFeed[int](&feed, 10, OverflowBlock)
+	)
+	defer sub.Unsubscribe()
+
+	// Publish returns while the subscriber is not reading.
+	for i := 0; i < 5; i++ {
+		if err := async.Publish(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		expectRecv(t, ch, i)
+	}
+	closed := make(chan struct{})
+	go func() {
+		async.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	if err := async.Publish(5); err != ErrFeedClosed {
+		t.Fatalf("Publish after Close returned %v, want ErrFeedClosed", err)
+	}
+}
+
+func TestAsyncFeedOverflow(t *testing.T) {
+	for _, overflow := range []OverflowPolicy{OverflowDrop, OverflowError} {
+		var (
+			feed  Feed
+			ch    = make(chan int)
+			sub   = feed.Subscribe(ch)
+			async = NewAsyncFeed[interface{}](&feed, 2, overflow)
+		)
+		// The dispatcher takes the first value and blocks on the subscriber, two more
+		// fill the queue.
+		async.Publish(0)
+		waitFor(t, func() bool { return async.Len() == 0 })
+		async.Publish(1)
+		async.Publish(2)
+
+		err := async.Publish(3)
+		if overflow == OverflowError && err != ErrQueueFull {
+			t.Errorf("overflow %d: Publish returned %v, want ErrQueueFull", overflow, err)
+		}
+		if overflow == OverflowDrop && err != nil {
+			t.Errorf("overflow %d: Publish returned %v", overflow, err)
+		}
+		if n := async.Dropped(); n != 1 {
+			t.Errorf("overflow %d: dropped %d values, want 1", overflow, n)
+		}
+		for i := 0; i < 3; i++ {
+			expectRecv(t, ch, i)
+		}
+		async.Close()
+		sub.Unsubscribe()
+	}
+}
+
+func TestAsyncFeedCloseFlushes(t *testing.T) {
+	var (
+		feed  FeedOf[int]
+		ch    = make(chan int, 100)
+		sub   = feed.Subscribe(ch)
+		async = NewAsyncFeed[int](&feed, 100, OverflowBlock)
+	)
+	defer sub.Unsubscribe()
+	for i := 0; i < 100; i++ {
+		async.Publish(i)
+	}
+	async.Close()
+	if len(ch) != 100 {
+		t.Fatalf("%d values delivered before Close returned, want 100", len(ch))
+	}
+	for i := 0; i < 100; i++ {
+		if v := <-ch; v != i {
+			t.Fatalf("received %d, want %d", v, i)
+		}
+	}
+}
@@ -23,16 +23,6 @@ type Subscription interface {
 
 type caseList []reflect.SelectCase
 
-// find returns the index of a case containing the given channel.
-func (cs caseList) find(channel interface{}) int {
-	for i, cas := range cs {
-		if cas.Chan.Interface() == channel {
-			return i
-		}
-	}
-	return -1
-}
-
 // delete removes the given case from cs.
 // the elements in original slice will change
 func (cs caseList) delete(index int) caseList {
@@ -46,3 +36,30 @@ func (cs caseList) deactivate(index int) caseList {
 	cs[index], cs[last] = cs[last], cs[index]
 	return cs[:last]
 }
+
+// subList holds the subscriptions owning the cases of a caseList at the same
+// indices. Every operation applied to the caseList must also be applied to its
+// subList to keep them aligned.
+type subList[S comparable] []S
+
+// find returns the index of the given subscription.
+func (sl subList[S]) find(sub S) int {
+	for i, s := range sl {
+		if s == sub {
+			return i
+		}
+	}
+	return -1
+}
+
+// delete removes the given subscription from sl, see caseList.delete.
+func (sl subList[S]) delete(index int) subList[S] {
+	return append(sl[:index], sl[index+1:]...)
+}
+
+// deactivate moves the subscription at index to the end of sl, see caseList.deactivate.
+func (sl subList[S]) deactivate(index int) subList[S] {
+	last := len(sl) - 1
+	sl[index], sl[last] = sl[last], sl[index]
+	return sl[:last]
+}
@@ -0,0 +1,161 @@
+package event
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+var errBadPattern = errors.New("event: wildcard must be the last topic segment")
+
+// Bus routes events to subscribers by topic string. Each topic carries a single
+// event type, determined by RegisterTopic or by the first SubscribeTopic or
+// PublishTopic call using it, and is backed by a FeedOf of that type.
+//
+// A topic ending in ".*" subscribes to every topic with that prefix, e.g. "block.*"
+// receives events published on "block.new" and "block.head.final". "*" alone
+// matches all topics. Wildcard subscriptions must use the same type as every topic
+// they match.
+//
+// Feeds are created when a topic or pattern gets its first subscriber and torn down
+// when the last one unsubscribes. The zero value is ready to use.
+type Bus struct {
+	reg      feedRegistry[string]           // feeds of topics and patterns, reg.mu protects the Bus
+	types    map[string]reflect.Type        // event type of every topic seen so far
+	patterns map[string]*sharedFeed[string] // subset of the feeds holding wildcard patterns
+}
+
+type busTypeError struct {
+	topic     string
+	got, want reflect.Type
+}
+
+func (e busTypeError) Error() string {
+	return "event: wrong type for topic " + e.topic + " got " + e.got.String() + ", want " + e.want.String()
+}
+
+func (b *Bus) initLocked() {
+	if b.types == nil {
+		b.types = make(map[string]reflect.Type)
+		b.patterns = make(map[string]*sharedFeed[string])
+		b.reg.teardown = func(sf *sharedFeed[string]) { delete(b.patterns, sf.key) }
+	}
+}
+
+// parsePattern reports whether topic is a wildcard pattern and returns its prefix.
+func parsePattern(topic string) (prefix string, wildcard bool, err error) {
+	i := strings.IndexByte(topic, '*')
+	if i == -1 {
+		return "", false, nil
+	}
+	if i != len(topic)-1 || (i > 0 && topic[i-1] != '.') {
+		return "", false, errBadPattern
+	}
+	return topic[:i], true, nil
+}
+
+// bindTopicLocked checks that topic carries etype, recording the type if the topic
+// is new. It must be called with b.mu held.
+func (b *Bus) bindTopicLocked(topic string, etype reflect.Type) error {
+	if want, ok := b.types[topic]; ok {
+		if want != etype {
+			return busTypeError{topic: topic, got: etype, want: want}
+		}
+		return nil
+	}
+	for pattern, pf := range b.patterns {
+		if strings.HasPrefix(topic, pattern[:len(pattern)-1]) && pf.etype != etype {
+			return busTypeError{topic: pattern, got: etype, want: pf.etype}
+		}
+	}
+	b.types[topic] = etype
+	return nil
+}
+
+// bindPatternLocked checks that every topic matching the pattern carries etype.
+// It must be called with b.mu held.
+func (b *Bus) bindPatternLocked(pattern, prefix string, etype reflect.Type) error {
+	if pf, ok := b.patterns[pattern]; ok {
+		if pf.etype != etype {
+			return busTypeError{topic: pattern, got: etype, want: pf.etype}
+		}
+		return nil
+	}
+	for topic, want := range b.types {
+		if strings.HasPrefix(topic, prefix) && want != etype {
+			return busTypeError{topic: topic, got: etype, want: want}
+		}
+	}
+	return nil
+}
+
+// RegisterTopic declares the event type of topic ahead of its first use.
+func RegisterTopic[T any](b *Bus, topic string) error {
+	if _, wildcard, err := parsePattern(topic); err != nil || wildcard {
+		return errBadPattern
+	}
+	b.reg.mu.Lock()
+	defer b.reg.mu.Unlock()
+	b.initLocked()
+	return b.bindTopicLocked(topic, reflect.TypeFor[T]())
+}
+
+// SubscribeTopic adds a channel receiving the events published on topic, which may
+// be a wildcard pattern. It fails if T does not match the type of the topic or of any
+// topic matched by the pattern.
+func SubscribeTopic[T any](b *Bus, topic string, channel chan<- T, opts ...SubscribeOption) (Subscription, error) {
+	prefix, wildcard, err := parsePattern(topic)
+	if err != nil {
+		return nil, err
+	}
+	etype := reflect.TypeFor[T]()
+
+	b.reg.mu.Lock()
+	defer b.reg.mu.Unlock()
+	b.initLocked()
+	if wildcard {
+		err = b.bindPatternLocked(topic, prefix, etype)
+	} else {
+		err = b.bindTopicLocked(topic, etype)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sub, sf, created := subscribeShared(&b.reg, topic, channel, opts)
+	if created && wildcard {
+		b.patterns[topic] = sf
+	}
+	return sub, nil
+}
+
+// PublishTopic delivers value to the subscribers of topic and of every wildcard
+// pattern matching it. It returns the number of subscribers the value was sent to.
+func PublishTopic[T any](b *Bus, topic string, value T) (nsent int, err error) {
+	if _, wildcard, err := parsePattern(topic); err != nil || wildcard {
+		return 0, errBadPattern
+	}
+
+	b.reg.mu.Lock()
+	b.initLocked()
+	if err = b.bindTopicLocked(topic, reflect.TypeFor[T]()); err != nil {
+		b.reg.mu.Unlock()
+		return 0, err
+	}
+	var feeds []*FeedOf[T]
+	if sf := b.reg.feeds[topic]; sf != nil {
+		feeds = append(feeds, sf.feed.(*FeedOf[T]))
+	}
+	for pattern, pf := range b.patterns {
+		if strings.HasPrefix(topic, pattern[:len(pattern)-1]) {
+			feeds = append(feeds, pf.feed.(*FeedOf[T]))
+		}
+	}
+	b.reg.mu.Unlock()
+
+	// Send outside the lock, blocking subscribers must not stall the whole bus.
+	for _, feed := range feeds {
+		nsent += feed.Send(value)
+	}
+	return nsent, nil
+}
@@ -0,0 +1,131 @@
+package event
+
+import (
+	"testing"
+)
+
+func TestBusTopics(t *testing.T) {
+	var (
+		bus    Bus
+		blocks = make(chan int, 10)
+		txs    = make(chan string, 10)
+	)
+	sub1, err := SubscribeTopic(&bus, "block.new", blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub1.Unsubscribe()
+	sub2, err := SubscribeTopic(&bus, "tx.new", txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub2.Unsubscribe()
+
+	if nsent, err := PublishTopic(&bus, "block.new", 1); nsent != 1 || err != nil {
+		t.Errorf("PublishTopic = %d, %v, want 1, nil", nsent, err)
+	}
+	if nsent, err := PublishTopic(&bus, "tx.new", "0xab"); nsent != 1 || err != nil {
+		t.Errorf("PublishTopic = %d, %v, want 1, nil", nsent, err)
+	}
+	if nsent, err := PublishTopic(&bus, "block.old", 2); nsent != 0 || err != nil {
+		t.Errorf("PublishTopic without subscribers = %d, %v, want 0, nil", nsent, err)
+	}
+	if v := <-blocks; v != 1 {
+		t.Errorf("received %d, want 1", v)
+	}
+	if v := <-txs; v != "0xab" {
+		t.Errorf("received %q, want 0xab", v)
+	}
+}
+
+func TestBusWildcard(t *testing.T) {
+	var (
+		bus Bus
+		all = make(chan int, 10)
+		one = make(chan int, 10)
+	)
+	wsub, err := SubscribeTopic(&bus, "block.*", all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wsub.Unsubscribe()
+	sub, err := SubscribeTopic(&bus, "block.new", one)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	if nsent, _ := PublishTopic(&bus, "block.new", 1); nsent != 2 {
+		t.Errorf("PublishTopic delivered %d times, want 2", nsent)
+	}
+	if nsent, _ := PublishTopic(&bus, "block.head.final", 2); nsent != 1 {
+		t.Errorf("PublishTopic delivered %d times, want 1", nsent)
+	}
+	if nsent, _ := PublishTopic(&bus, "blocks", 3); nsent != 0 {
+		t.Errorf("PublishTopic on non-matching topic delivered %d times, want 0", nsent)
+	}
+	if v1, v2 := <-all, <-all; v1 != 1 || v2 != 2 {
+		t.Errorf("wildcard received %d, %d, want 1, 2", v1, v2)
+	}
+	if v := <-one; v != 1 {
+		t.Errorf("received %d, want 1", v)
+	}
+}
+
+func TestBusTypeCheck(t *testing.T) {
+	var bus Bus
+	if err := RegisterTopic[int](&bus, "block.new"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SubscribeTopic(&bus, "block.new", make(chan string)); err == nil {
+		t.Errorf("subscribed with wrong type")
+	}
+	if _, err := PublishTopic(&bus, "block.new", "x"); err == nil {
+		t.Errorf("published with wrong type")
+	}
+	// A wildcard must agree with every topic it matches.
+	if _, err := SubscribeTopic(&bus, "block.*", make(chan string)); err == nil {
+		t.Errorf("subscribed wildcard with wrong type")
+	}
+	sub, err := SubscribeTopic(&bus, "tx.*", make(chan string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = RegisterTopic[int](&bus, "tx.new"); err == nil {
+		t.Errorf("registered topic conflicting with wildcard")
+	}
+	sub.Unsubscribe()
+	// Once the wildcard is gone, the topic is free to choose its type.
+	if err = RegisterTopic[int](&bus, "tx.new"); err != nil {
+		t.Errorf("register after wildcard teardown: %v", err)
+	}
+
+	for _, bad := range []string{"block*", "block.*.new", "*.new"} {
+		if _, err := SubscribeTopic(&bus, bad, make(chan int)); err != errBadPattern {
+			t.Errorf("pattern %q: got error %v, want %v", bad, err, errBadPattern)
+		}
+	}
+}
+
+func TestBusTeardown(t *testing.T) {
+	var bus Bus
+	sub1, _ := SubscribeTopic(&bus, "block.new", make(chan int, 1))
+	sub2, _ := SubscribeTopic(&bus, "block.new", make(chan int, 1))
+	wsub, _ := SubscribeTopic(&bus, "*", make(chan int, 1))
+	if len(bus.reg.feeds) != 2 {
+		t.Fatalf("%d feeds, want 2", len(bus.reg.feeds))
+	}
+	sub1.Unsubscribe()
+	sub1.Unsubscribe()
+	if len(bus.reg.feeds) != 2 {
+		t.Errorf("feed torn down while subscribed")
+	}
+	sub2.Unsubscribe()
+	wsub.Unsubscribe()
+	if len(bus.reg.feeds) != 0 || len(bus.patterns) != 0 {
+		t.Errorf("%d feeds and %d patterns left after unsubscribe", len(bus.reg.feeds), len(bus.patterns))
+	}
+	if nsent, _ := PublishTopic(&bus, "block.new", 1); nsent != 0 {
+		t.Errorf("PublishTopic delivered %d times after teardown", nsent)
+	}
+}
@@ -0,0 +1,167 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func expectErrClosed(t *testing.T, sub Subscription) {
+	t.Helper()
+	select {
+	case err, ok := <-sub.Err():
+		if ok {
+			t.Errorf("Err() delivered %v, want closed channel", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Err() channel not closed")
+	}
+}
+
+func TestFeedOfClose(t *testing.T) {
+	var feed FeedOf[int]
+	feed.SetReplay(1)
+	feed.Send(0)
+	blocked := feed.Subscribe(make(chan int))
+	ring := feed.Subscribe(make(chan int), WithPolicy(DropOldest))
+	replayed := feed.Subscribe(make(chan int))
+
+	// Close interrupts a Send waiting for the blocked subscribers.
+	errc := make(chan error)
+	go func() {
+		_, _, err := feed.SendContext(context.Background(), 1)
+		errc <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	feed.Close()
+	if err := <-errc; err != ErrFeedClosed {
+		t.Errorf("SendContext interrupted by Close returned %v, want ErrFeedClosed", err)
+	}
+	for _, sub := range []Subscription{blocked, ring, replayed} {
+		expectErrClosed(t, sub)
+		sub.Unsubscribe()
+	}
+
+	if n := feed.Send(2); n != 0 {
+		t.Errorf("Send after Close delivered to %d subscribers", n)
+	}
+	if _, _, err := feed.SendContext(context.Background(), 2); err != ErrFeedClosed {
+		t.Errorf("SendContext after Close returned %v, want ErrFeedClosed", err)
+	}
+	sub := feed.Subscribe(make(chan int))
+	if err := <-sub.Err(); err != ErrFeedClosed {
+		t.Errorf("Subscribe after Close failed with %v, want ErrFeedClosed", err)
+	}
+	sub.Unsubscribe()
+	feed.Close()
+}
+
+func TestFeedOfCloseChannels(t *testing.T) {
+	var (
+		feed   FeedOf[int]
+		shared = make(chan int, 10)
+		ring   = make(chan int, 10)
+	)
+	feed.Subscribe(shared)
+	feed.Subscribe(shared)
+	feed.Subscribe(ring, WithPolicy(DropOldest))
+	feed.Send(1)
+	waitFor(t, func() bool { return len(ring) == 1 })
+	feed.Close(CloseChannels())
+
+	var got []int
+	for v := range shared {
+		got = append(got, v)
+	}
+	if len(got) != 2 {
+		t.Errorf("received %v from shared channel, want two values", got)
+	}
+	for range ring {
+	}
+}
+
+func TestFeedClose(t *testing.T) {
+	var feed Feed
+	ch := make(chan int)
+	sub := feed.Subscribe(ch)
+	nonblocking := feed.Subscribe(make(chan int), WithPolicy(DropNewest))
+
+	done := make(chan int)
+	go func() { done <- feed.Send(1) }()
+	time.Sleep(10 * time.Millisecond)
+	feed.Close(CloseChannels())
+	<-done
+	expectErrClosed(t, sub)
+	expectErrClosed(t, nonblocking)
+	if _, ok := <-ch; ok {
+		t.Error("channel not closed")
+	}
+	if n := feed.Send(2); n != 0 {
+		t.Errorf("Send after Close delivered to %d subscribers", n)
+	}
+	sub.Unsubscribe()
+	nonblocking.Unsubscribe()
+}
+
+func TestFeedCloseBeforeUse(t *testing.T) {
+	var feed Feed
+	feed.Close()
+	sub := feed.Subscribe(make(chan int))
+	if err := <-sub.Err(); err != ErrFeedClosed {
+		t.Errorf("Subscribe after Close failed with %v, want ErrFeedClosed", err)
+	}
+	sub.Unsubscribe()
+	if _, _, err := feed.SendContext(context.Background(), 1); err != ErrFeedClosed {
+		t.Errorf("SendContext after Close returned %v, want ErrFeedClosed", err)
+	}
+}
+
+func TestFeedOfCloseUnsubscribeRace(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		var (
+			feed FeedOf[int]
+			subs []Subscription
+			wg   sync.WaitGroup
+		)
+		for j := 0; j < 5; j++ {
+			subs = append(subs, feed.Subscribe(make(chan int, 1)))
+		}
+		feed.Send(1)
+		wg.Add(len(subs))
+		for _, sub := range subs {
+			go func() {
+				defer wg.Done()
+				sub.Unsubscribe()
+			}()
+		}
+		feed.Close()
+		wg.Wait()
+		for _, sub := range subs {
+			expectErrClosed(t, sub)
+		}
+	}
+}
+
+func TestFeedOfCloseAdapters(t *testing.T) {
+	var (
+		feed     FeedOf[int]
+		envelope EnvelopeFeed[int]
+	)
+	subs := []Subscription{
+		Map(&feed, func(v int) int { return v }, make(chan int)),
+		Batch(&feed, 2, 0, make(chan []int)),
+		Debounce(&feed, time.Second, make(chan int)),
+		Throttle(&feed, time.Second, make(chan int)),
+		feed.SubscribeHandler(func(int) {}),
+		envelope.SubscribeValues(make(chan int)),
+	}
+	// The Map subscriber is blocked delivering a value when the feed is closed.
+	feed.Send(1)
+	feed.Close()
+	envelope.Close()
+	for _, sub := range subs {
+		expectErrClosed(t, sub)
+		sub.Unsubscribe()
+	}
+}
@@ -0,0 +1,114 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Envelope wraps a value sent on an EnvelopeFeed with delivery metadata.
+type Envelope[T any] struct {
+	Seq     uint64    // position of the value in its feed, starting at 1
+	Time    time.Time // when Send was called
+	Source  string    // EnvelopeFeed.Source
+	TraceID string    // taken from the context passed to Send, if any
+	SpanID  string
+	Value   T
+}
+
+type traceKey struct{}
+
+type traceIDs struct {
+	trace, span string
+}
+
+// WithTrace returns a context carrying trace and span IDs which EnvelopeFeed.Send
+// stamps on the values it sends.
+func WithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return context.WithValue(ctx, traceKey{}, traceIDs{traceID, spanID})
+}
+
+// TraceFromContext returns the IDs stored by WithTrace.
+func TraceFromContext(ctx context.Context) (traceID, spanID string) {
+	ids, _ := ctx.Value(traceKey{}).(traceIDs)
+	return ids.trace, ids.span
+}
+
+// EnvelopeFeed is a FeedOf delivering every value in an Envelope stamped with a
+// sequence number, the send time, the feed's Source and the trace IDs of the sending
+// context. Sequence numbers are assigned in delivery order.
+//
+// The zero value is ready to use. Source and TraceFunc must be set before the first
+// Send.
+type EnvelopeFeed[T any] struct {
+	// Source names the feed in the envelopes.
+	Source string
+	// TraceFunc extracts the trace and span IDs from the context passed to Send,
+	// e.g. to use those of a tracing library. It defaults to TraceFromContext.
+	TraceFunc func(ctx context.Context) (traceID, spanID string)
+
+	mu   sync.Mutex // orders sequence numbers with sends
+	seq  uint64
+	feed FeedOf[Envelope[T]]
+}
+
+// Send wraps value in an envelope and delivers it to all subscribers. Like
+// FeedOf.SendContext, it stops waiting for blocked subscribers when ctx is done and
+// returns the number of subscribers that the value was sent to, the subscriptions
+// which missed it and the error.
+//
+// Sends are serialized to keep the sequence numbers in delivery order, so a Send
+// waiting for a slow subscriber holds up the others until its ctx is done.
+func (f *EnvelopeFeed[T]) Send(ctx context.Context, value T) (nsent int, missed []Subscription, err error) {
+	trace := f.TraceFunc
+	if trace == nil {
+		trace = TraceFromContext
+	}
+	traceID, spanID := trace(ctx)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	return f.feed.SendContext(ctx, Envelope[T]{
+		Seq:     f.seq,
+		Time:    time.Now(),
+		Source:  f.Source,
+		TraceID: traceID,
+		SpanID:  spanID,
+		Value:   value,
+	})
+}
+
+// Subscribe adds a channel receiving the envelopes, see FeedOf.Subscribe.
+func (f *EnvelopeFeed[T]) Subscribe(channel chan<- Envelope[T], opts ...SubscribeOption) Subscription {
+	return f.feed.Subscribe(channel, opts...)
+}
+
+// SubscribeValues adds a channel receiving only the values, for consumers which do
+// not care about the metadata.
+func (f *EnvelopeFeed[T]) SubscribeValues(channel chan<- T, opts ...SubscribeOption) Subscription {
+	return Map(&f.feed, Envelope[T].Unwrap, channel, opts...)
+}
+
+// Close closes the feed, see FeedOf.Close.
+func (f *EnvelopeFeed[T]) Close(opts ...CloseOption) {
+	f.feed.Close(opts...)
+}
+
+// Unwrap returns the wrapped value.
+func (e Envelope[T]) Unwrap() T {
+	return e.Value
+}
+
+// Values forwards the values of the envelopes received on in to the returned channel,
+// which is closed when in is closed, e.g. by Close with CloseChannels.
+func Values[T any](in <-chan Envelope[T]) <-chan T {
+	out := make(chan T, cap(in))
+	go func() {
+		defer close(out)
+		for e := range in {
+			out <- e.Value
+		}
+	}()
+	return out
+}
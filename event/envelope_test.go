@@ -0,0 +1,113 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeFeed(t *testing.T) {
+	feed := EnvelopeFeed[string]{Source: "blocks"}
+	ch := make(chan Envelope[string], 10)
+	sub := feed.Subscribe(ch)
+	defer sub.Unsubscribe()
+
+	before := time.Now()
+	ctx := WithTrace(context.Background(), "trace-1", "span-1")
+	if n, missed, err := feed.Send(ctx, "a"); n != 1 || missed != nil || err != nil {
+		t.Fatalf("Send = %d, %v, %v, want delivery to 1 subscriber", n, missed, err)
+	}
+	feed.Send(context.Background(), "b")
+
+	e := <-ch
+	if e.Seq != 1 || e.Source != "blocks" || e.TraceID != "trace-1" || e.SpanID != "span-1" || e.Unwrap() != "a" {
+		t.Errorf("first envelope %+v", e)
+	}
+	if e.Time.Before(before) || e.Time.After(time.Now()) {
+		t.Errorf("envelope time %v out of range", e.Time)
+	}
+	e = <-ch
+	if e.Seq != 2 || e.TraceID != "" || e.Value != "b" {
+		t.Errorf("second envelope %+v", e)
+	}
+}
+
+func TestEnvelopeFeedSendContext(t *testing.T) {
+	var feed EnvelopeFeed[int]
+	sub := feed.Subscribe(make(chan Envelope[int]))
+	defer sub.Unsubscribe()
+
+	// The subscriber never receives, so Send ends with the context.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	n, missed, err := feed.Send(ctx, 1)
+	if n != 0 || len(missed) != 1 || missed[0] != sub || err != context.DeadlineExceeded {
+		t.Fatalf("Send = %d, %v, %v, want the subscriber missed and DeadlineExceeded", n, missed, err)
+	}
+}
+
+func TestEnvelopeFeedTraceFunc(t *testing.T) {
+	type spanKey struct{}
+	feed := EnvelopeFeed[int]{
+		TraceFunc: func(ctx context.Context) (string, string) {
+			span, _ := ctx.Value(spanKey{}).(string)
+			return "custom", span
+		},
+	}
+	ch := make(chan Envelope[int], 1)
+	sub := feed.Subscribe(ch)
+	defer sub.Unsubscribe()
+	feed.Send(context.WithValue(context.Background(), spanKey{}, "s"), 1)
+	if e := <-ch; e.TraceID != "custom" || e.SpanID != "s" {
+		t.Errorf("envelope trace %q span %q", e.TraceID, e.SpanID)
+	}
+}
+
+// Sequence numbers must follow delivery order even with concurrent senders.
+func TestEnvelopeFeedSeqOrder(t *testing.T) {
+	var (
+		feed EnvelopeFeed[int]
+		ch   = make(chan Envelope[int], 1000)
+		wg   sync.WaitGroup
+	)
+	sub := feed.Subscribe(ch)
+	defer sub.Unsubscribe()
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				feed.Send(context.Background(), j)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := uint64(1); i <= 1000; i++ {
+		if e := <-ch; e.Seq != i {
+			t.Fatalf("received seq %d, want %d", e.Seq, i)
+		}
+	}
+}
+
+func TestEnvelopeFeedValues(t *testing.T) {
+	var feed EnvelopeFeed[int]
+	values := make(chan int)
+	sub := feed.SubscribeValues(values)
+	defer sub.Unsubscribe()
+	envelopes := make(chan Envelope[int], 2)
+	feed.Subscribe(envelopes)
+
+	feed.Send(context.Background(), 1)
+	expectRecv(t, values, 1)
+	feed.Send(context.Background(), 2)
+	expectRecv(t, values, 2)
+
+	feed.Close(CloseChannels())
+	out := Values(envelopes)
+	expectRecv(t, out, 1)
+	expectRecv(t, out, 2)
+	if _, ok := <-out; ok {
+		t.Error("Values channel not closed")
+	}
+}
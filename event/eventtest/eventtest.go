@@ -0,0 +1,239 @@
+// Package eventtest provides helpers for testing code built on package event without
+// relying on sleeps: recording subscribers, assertions with timeouts and a slow
+// subscriber whose pace the test controls.
+package eventtest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go-common-utils/event"
+)
+
+// DefaultTimeout is how long the assertions wait for events unless the Timeout of
+// the Recorder is set.
+const DefaultTimeout = 2 * time.Second
+
+// Subscriber is implemented by the typed feeds of package event, e.g. FeedOf[T] and
+// EnvelopeFeed with T = Envelope.
+type Subscriber[T any] interface {
+	Subscribe(channel chan<- T, opts ...event.SubscribeOption) event.Subscription
+}
+
+// Recorder is a subscriber recording every value it receives. Its channel is
+// unbuffered, so a Send completes only once the value is recorded.
+//
+// A Recorder created by RecordSlow receives values only as allowed by Accept and
+// Resume, blocking Send meanwhile.
+type Recorder[T any] struct {
+	// Timeout overrides DefaultTimeout in the assertions.
+	Timeout time.Duration
+
+	sub      event.Subscription
+	ch       chan T
+	quit     chan struct{}
+	quitOnce sync.Once
+	done     chan struct{} // closed when run exits
+
+	mu      sync.Mutex
+	values  []T
+	checked int           // number of values consumed by ExpectEvents
+	changed chan struct{} // closed and replaced when values or err change
+	err     error         // error of the subscription, if it failed
+	permits int           // values a slow recorder may still receive, -1 if unlimited
+}
+
+// Record subscribes a Recorder to feed.
+func Record[T any](feed Subscriber[T], opts ...event.SubscribeOption) *Recorder[T] {
+	return newRecorder(feed, -1, opts)
+}
+
+// RecordSlow subscribes a Recorder to feed which does not receive anything until
+// Accept or Resume is called.
+func RecordSlow[T any](feed Subscriber[T], opts ...event.SubscribeOption) *Recorder[T] {
+	return newRecorder(feed, 0, opts)
+}
+
+func newRecorder[T any](feed Subscriber[T], permits int, opts []event.SubscribeOption) *Recorder[T] {
+	r := &Recorder[T]{
+		ch:      make(chan T),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		changed: make(chan struct{}),
+		permits: permits,
+	}
+	r.sub = feed.Subscribe(r.ch, opts...)
+	go r.run()
+	return r
+}
+
+func (r *Recorder[T]) run() {
+	defer close(r.done)
+	for {
+		if !r.waitPermit() {
+			return
+		}
+		select {
+		case v := <-r.ch:
+			r.mu.Lock()
+			r.values = append(r.values, v)
+			r.notifyLocked()
+			r.mu.Unlock()
+		case err, ok := <-r.sub.Err():
+			if ok {
+				r.mu.Lock()
+				r.err = err
+				r.notifyLocked()
+				r.mu.Unlock()
+			}
+			return
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// waitPermit blocks until the recorder may receive another value. It returns false
+// if the recorder was unsubscribed.
+func (r *Recorder[T]) waitPermit() bool {
+	for {
+		r.mu.Lock()
+		if r.permits != 0 {
+			if r.permits > 0 {
+				r.permits--
+			}
+			r.mu.Unlock()
+			return true
+		}
+		changed := r.changed
+		r.mu.Unlock()
+		select {
+		case <-changed:
+		case <-r.quit:
+			return false
+		}
+	}
+}
+
+// notifyLocked wakes up everyone waiting for a change. It must be called with r.mu
+// held.
+func (r *Recorder[T]) notifyLocked() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Accept lets a slow recorder receive n more values.
+func (r *Recorder[T]) Accept(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.permits >= 0 {
+		r.permits += n
+		r.notifyLocked()
+	}
+}
+
+// Pause stops the recorder from receiving values until Accept or Resume is called. A
+// value being received already is still recorded.
+func (r *Recorder[T]) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.permits = 0
+}
+
+// Resume lets the recorder receive values freely.
+func (r *Recorder[T]) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.permits = -1
+	r.notifyLocked()
+}
+
+// Values returns all values recorded so far.
+func (r *Recorder[T]) Values() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]T(nil), r.values...)
+}
+
+// Err returns the error the subscription failed with, if any.
+func (r *Recorder[T]) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Unsubscribe cancels the subscription and waits for the recorder to stop.
+func (r *Recorder[T]) Unsubscribe() {
+	r.quitOnce.Do(func() { close(r.quit) })
+	r.sub.Unsubscribe()
+	<-r.done
+}
+
+func (r *Recorder[T]) timeout() time.Duration {
+	if r.Timeout > 0 {
+		return r.Timeout
+	}
+	return DefaultTimeout
+}
+
+// ExpectEvents waits until rec recorded len(want) values after those checked by
+// earlier calls, and fails the test unless they equal want. A failed subscription
+// fails the test as well.
+func ExpectEvents[T any](t testing.TB, rec *Recorder[T], want ...T) {
+	t.Helper()
+	timer := time.NewTimer(rec.timeout())
+	defer timer.Stop()
+
+	rec.mu.Lock()
+	for len(rec.values)-rec.checked < len(want) && rec.err == nil {
+		changed := rec.changed
+		rec.mu.Unlock()
+		select {
+		case <-changed:
+		case <-timer.C:
+			rec.mu.Lock()
+			got := rec.values[rec.checked:]
+			rec.mu.Unlock()
+			t.Fatalf("timed out after %v waiting for events: got %v, want %v", rec.timeout(), got, want)
+		}
+		rec.mu.Lock()
+	}
+	err := rec.err
+	got := append([]T(nil), rec.values[rec.checked:]...)
+	if len(got) > len(want) {
+		got = got[:len(want)]
+	}
+	rec.checked += len(got)
+	rec.mu.Unlock()
+
+	if err != nil && len(got) < len(want) {
+		t.Fatalf("subscription failed with %v after events %v, want %v", err, got, want)
+	}
+	if !reflect.DeepEqual(got, want) && (len(got) != 0 || len(want) != 0) {
+		t.Fatalf("got events %v, want %v", got, want)
+	}
+}
+
+// ExpectNoEvents fails the test if rec records a value beyond those checked by
+// ExpectEvents within d.
+func ExpectNoEvents[T any](t testing.TB, rec *Recorder[T], d time.Duration) {
+	t.Helper()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		rec.mu.Lock()
+		extra := rec.values[rec.checked:]
+		changed := rec.changed
+		rec.mu.Unlock()
+		if len(extra) > 0 {
+			t.Fatalf("got unexpected events %v", extra)
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return
+		}
+	}
+}
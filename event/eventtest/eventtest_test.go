@@ -0,0 +1,97 @@
+package eventtest
+
+import (
+	"testing"
+	"time"
+
+	"go-common-utils/event"
+)
+
+func TestRecorder(t *testing.T) {
+	var feed event.FeedOf[int]
+	rec := Record[int](&feed)
+	defer rec.Unsubscribe()
+
+	for i := 0; i < 3; i++ {
+		feed.Send(i)
+	}
+	ExpectEvents(t, rec, 0, 1)
+	ExpectEvents(t, rec, 2)
+	ExpectNoEvents(t, rec, 10*time.Millisecond)
+	if got := rec.Values(); len(got) != 3 {
+		t.Errorf("recorded %v, want three values", got)
+	}
+}
+
+func TestRecordSlow(t *testing.T) {
+	var (
+		feed event.FeedOf[int]
+		fast = Record[int](&feed)
+		slow = RecordSlow[int](&feed)
+	)
+	defer fast.Unsubscribe()
+	defer slow.Unsubscribe()
+
+	done := make(chan int)
+	go func() {
+		for i := 0; i < 3; i++ {
+			feed.Send(i)
+		}
+		close(done)
+	}()
+	// Send is stuck on the slow subscriber until it accepts the value.
+	ExpectEvents(t, fast, 0)
+	ExpectNoEvents(t, fast, 10*time.Millisecond)
+	slow.Accept(1)
+	ExpectEvents(t, slow, 0)
+	ExpectEvents(t, fast, 1)
+
+	slow.Resume()
+	ExpectEvents(t, slow, 1, 2)
+	ExpectEvents(t, fast, 2)
+	<-done
+}
+
+func TestExpectEventsFailure(t *testing.T) {
+	var feed event.FeedOf[int]
+	rec := Record[int](&feed)
+	defer rec.Unsubscribe()
+	rec.Timeout = 10 * time.Millisecond
+
+	feed.Send(1)
+	ft := &fakeTB{TB: t}
+	runFatal(func() { ExpectEvents(ft, rec, 2) })
+	if !ft.failed {
+		t.Error("ExpectEvents accepted a wrong event")
+	}
+	ft = &fakeTB{TB: t}
+	runFatal(func() { ExpectEvents(ft, rec, 3) })
+	if !ft.failed {
+		t.Error("ExpectEvents did not time out")
+	}
+}
+
+// fakeTB records failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Fatalf(format string, args ...interface{}) {
+	tb.failed = true
+	panic(tb)
+}
+
+// runFatal runs f, recovering from the panic of fakeTB.Fatalf.
+func runFatal(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*fakeTB); !ok {
+				panic(r)
+			}
+		}
+	}()
+	f()
+}
@@ -1,9 +1,12 @@
 package event
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
 var errBadChannel = errors.New("event: Subscribe argument does not have sendable channel type")
@@ -15,15 +18,35 @@ var errBadChannel = errors.New("event: Subscribe argument does not have sendable
 // Subscribe operation. Subsequent calls to these methods panic if the type does not
 // match.
 type Feed struct {
-	once      sync.Once        // ensures that init only runs once
-	sendLock  chan struct{}    // sendLock has a one-element buffer and is empty when held.It protects sendCases.
-	removeSub chan interface{} // interrupts Send
-	sendCases caseList         // the active set of select cases used by Send
+	once      sync.Once         // ensures that init only runs once
+	sendLock  chan struct{}     // sendLock has a one-element buffer and is empty when held.It protects sendCases.
+	removeSub chan *FeedSub     // interrupts Send
+	sendCases caseList          // the active set of select cases used by Send
+	sendSubs  subList[*FeedSub] // owners of sendCases, sendSubs[0] is nil
 
 	// The inbox holds newly subscribed channels until they are added to sendCases.
-	mu    sync.Mutex
-	inbox caseList
-	etype reflect.Type
+	mu        sync.Mutex
+	inbox     caseList
+	inboxSubs subList[*FeedSub]
+	etype     reflect.Type // nil if the feed was closed before its first use
+
+	closed bool          // set by Close, protected by mu
+	quit   chan struct{} // closed by Close, interrupts Send
+
+	// nonblocking holds subscriptions whose policy never blocks Send. It is
+	// protected by mu and served by Send without using sendCases.
+	nonblocking []*FeedSub
+
+	// prioritized is set once a subscription uses WithPriority or
+	// SkipUnderBackpressure. Send then keeps sendCases sorted by priority and
+	// delivers tier by tier. Protected by mu.
+	prioritized bool
+
+	// subs holds every subscription for Stats. It is protected by mu, like the
+	// hook set by SetMetrics.
+	subs     map[*FeedSub]struct{}
+	metrics  FeedMetrics
+	counters feedCounters
 }
 
 // This is the index of the first actual subscription channel in sendCases.
@@ -41,36 +64,69 @@ func (e feedTypeError) Error() string {
 
 func (f *Feed) init(etype reflect.Type) {
 	f.etype = etype
-	f.removeSub = make(chan interface{})
+	f.removeSub = make(chan *FeedSub)
+	f.quit = make(chan struct{})
 	f.sendLock = make(chan struct{}, 1)
 	f.sendLock <- struct{}{}
 	f.sendCases = caseList{{Chan: reflect.ValueOf(f.removeSub), Dir: reflect.SelectRecv}}
+	f.sendSubs = subList[*FeedSub]{nil}
 }
 
 // Subscribe adds a channel to the feed. Future sends will be delivered on the channel
 // until the subscription is canceled. All channels added must have the same element type.
 //
 // The channel should have ample buffer space to avoid blocking other subscribers.
-// Slow subscribers are not dropped.
-func (f *Feed) Subscribe(channel interface{}) Subscription {
+// Slow subscribers are not dropped unless a DeliveryPolicy other than Block is
+// given with WithPolicy.
+//
+// If the feed is closed, the subscription fails immediately with ErrFeedClosed.
+func (f *Feed) Subscribe(channel interface{}, opts ...SubscribeOption) Subscription {
 	chanval := reflect.ValueOf(channel)
 	chantyp := chanval.Type()
 	if chantyp.Kind() != reflect.Chan || chantyp.ChanDir()&reflect.SendDir == 0 {
 		panic(errBadChannel)
 	}
-	sub := &FeedSub{feed: f, channel: chanval, err: make(chan error, 1)}
+	o := newSubOptions(opts)
+	sub := &FeedSub{
+		feed:      f,
+		channel:   chanval,
+		policy:    o.policy,
+		priority:  o.priority,
+		skippable: o.skippable,
+		err:       make(chan error, 1),
+	}
 
 	f.once.Do(func() { f.init(chantyp.Elem()) })
-	if f.etype != chantyp.Elem() {
-		panic(feedTypeError{op: "Subscribe", got: chantyp, want: reflect.ChanOf(reflect.SendDir, f.etype)})
-	}
 
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	if f.closed {
+		sub.err <- ErrFeedClosed
+		return sub
+	}
+	if f.etype != chantyp.Elem() {
+		panic(feedTypeError{op: "Subscribe", got: chantyp, want: reflect.ChanOf(reflect.SendDir, f.etype)})
+	}
+	if f.subs == nil {
+		f.subs = make(map[*FeedSub]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	if sub.policy != Block {
+		if sub.policy == DropOldest {
+			sub.ring = newRing[reflect.Value](o.ringSize)
+			go sub.ring.run(sub.forward)
+		}
+		f.nonblocking = append(f.nonblocking, sub)
+		return sub
+	}
+	if sub.priority != 0 || sub.skippable {
+		f.prioritized = true
+	}
 	// Add the select case to the inbox.
 	// The next Send will add it to f.sendCases.
 	cas := reflect.SelectCase{Dir: reflect.SelectSend, Chan: chanval}
 	f.inbox = append(f.inbox, cas)
+	f.inboxSubs = append(f.inboxSubs, sub)
 	return sub
 }
 
@@ -78,33 +134,90 @@ func (f *Feed) remove(sub Subscription) {
 	// Delete from inbox first, which covers channels
 	// that have not been added to f.sendCases yet.
 	feedSub := sub.(*FeedSub)
-	ch := feedSub.channel.Interface()
 	f.mu.Lock()
-	index := f.inbox.find(ch)
+	if f.closed {
+		// Close ends all subscriptions.
+		f.mu.Unlock()
+		return
+	}
+	delete(f.subs, feedSub)
+	if feedSub.policy != Block {
+		f.removeNonblocking(feedSub)
+		f.mu.Unlock()
+		return
+	}
+	index := f.inboxSubs.find(feedSub)
 	if index != -1 {
 		f.inbox = f.inbox.delete(index)
+		f.inboxSubs = f.inboxSubs.delete(index)
 		f.mu.Unlock()
 		return
 	}
 	f.mu.Unlock()
 
 	select {
-	case f.removeSub <- ch:
+	case f.removeSub <- feedSub:
 		// Send will remove the channel from f.sendCases.
 	case <-f.sendLock:
 		// No Send is in progress, delete the channel now that we have the send lock.
-		f.sendCases = f.sendCases.delete(f.sendCases.find(ch))
+		// It is gone already if Close ran in the meantime.
+		if index := f.sendSubs.find(feedSub); index != -1 {
+			f.sendCases = f.sendCases.delete(index)
+			f.sendSubs = f.sendSubs.delete(index)
+		}
 		f.sendLock <- struct{}{}
 	}
 }
 
+// removeNonblocking deletes sub from f.nonblocking. It must be called with f.mu held.
+func (f *Feed) removeNonblocking(sub *FeedSub) {
+	for i, s := range f.nonblocking {
+		if s == sub {
+			f.nonblocking = append(f.nonblocking[:i:i], f.nonblocking[i+1:]...)
+			return
+		}
+	}
+}
+
 // Send delivers to all subscribed channels simultaneously.
-// It returns the number of subscribers that the value was sent to.
+// It returns the number of subscribers that the value was sent to, which is zero
+// once the feed is closed.
 func (f *Feed) Send(value interface{}) (nsent int) {
+	nsent, _, _ = f.send(nil, value)
+	return nsent
+}
+
+// SendContext delivers to all subscribed channels like Send, but stops waiting for
+// blocked subscribers when ctx is done. It returns the number of subscribers that the
+// value was sent to and, if ctx expired first, the subscriptions which did not receive
+// the value along with ctx.Err(). Subscriptions with a non-blocking DeliveryPolicy
+// never cause SendContext to wait and are not reported as missed.
+//
+// If the feed is closed before or during the call, the error is ErrFeedClosed.
+func (f *Feed) SendContext(ctx context.Context, value interface{}) (nsent int, missed []Subscription, err error) {
+	nsent, subs, err := f.send(ctx.Done(), value)
+	if len(subs) == 0 {
+		return nsent, nil, err
+	}
+	missed = make([]Subscription, len(subs))
+	for i, sub := range subs {
+		missed[i] = sub
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return nsent, missed, err
+}
+
+// send implements Send and SendContext. Delivery is abandoned when done is closed or
+// the feed is closed, in which case the subscriptions still waiting for the value are
+// returned.
+func (f *Feed) send(done <-chan struct{}, value interface{}) (nsent int, missed []*FeedSub, err error) {
+	start := time.Now()
 	rvalue := reflect.ValueOf(value)
 
 	f.once.Do(func() { f.init(rvalue.Type()) })
-	if f.etype != rvalue.Type() {
+	if f.etype != rvalue.Type() && f.etype != nil {
 		panic(feedTypeError{op: "Send", got: rvalue.Type(), want: f.etype})
 	}
 
@@ -112,8 +225,35 @@ func (f *Feed) Send(value interface{}) (nsent int) {
 
 	// Add new cases from the inbox after taking the send lock.
 	f.mu.Lock()
-	f.sendCases = append(f.sendCases, f.inbox...)
-	f.inbox = nil
+	if f.closed {
+		f.mu.Unlock()
+		f.sendLock <- struct{}{}
+		return 0, nil, ErrFeedClosed
+	}
+	metrics, prioritized := f.metrics, f.prioritized
+	if len(f.inbox) > 0 {
+		f.sendCases = append(f.sendCases, f.inbox...)
+		f.sendSubs = append(f.sendSubs, f.inboxSubs...)
+		f.inbox = nil
+		f.inboxSubs = nil
+		if prioritized {
+			sort.Stable(byPriority{f.sendCases[firstSubSendCase:], f.sendSubs[firstSubSendCase:]})
+		}
+	}
+	// Serve subscriptions that never block while holding mu, so that Unsubscribe
+	// cannot return while a delivery to them is in progress.
+	for i := 0; i < len(f.nonblocking); i++ {
+		sub := f.nonblocking[i]
+		sent, drop := sub.deliver(rvalue)
+		if sent {
+			nsent++
+		}
+		if drop {
+			f.removeNonblocking(sub)
+			delete(f.subs, sub)
+			i--
+		}
+	}
 	f.mu.Unlock()
 
 	// Set the sent value on all channels.
@@ -121,61 +261,297 @@ func (f *Feed) Send(value interface{}) (nsent int) {
 		f.sendCases[i].Send = rvalue
 	}
 
-	// Send until all channels except removeSub have been chosen. 'cases' tracks a prefix
-	// of sendCases. When a send succeeds, the corresponding case moves to the end of
-	// 'cases' and it shrinks by one element.
-	cases := f.sendCases
+	s := feedSend{
+		value:    rvalue,
+		metrics:  metrics,
+		doneCase: reflect.SelectCase{Dir: reflect.SelectRecv},
+		quitCase: reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(f.quit)},
+		nsent:    nsent,
+	}
+	if done != nil {
+		// A nil done channel leaves the Chan of doneCase invalid, which makes Select
+		// ignore the case.
+		s.doneCase.Chan = reflect.ValueOf(done)
+	}
+	if prioritized {
+		f.deliverTiers(&s)
+	} else {
+		f.deliver(&s, f.sendCases, f.sendSubs, false)
+	}
+
+	// Forget about the sent value and hand off the send lock.
+	for i := firstSubSendCase; i < len(f.sendCases); i++ {
+		f.sendCases[i].Send = reflect.Value{}
+	}
+	f.sendLock <- struct{}{}
+	f.counters.sendDone(metrics, s.nsent, start, !s.blockStart.IsZero())
+	return s.nsent, s.missed, s.err
+}
+
+// feedSend holds the state of a Send in progress.
+type feedSend struct {
+	value      reflect.Value
+	metrics    FeedMetrics
+	doneCase   reflect.SelectCase
+	quitCase   reflect.SelectCase
+	blockStart time.Time // set once a subscriber blocks
+	nsent      int
+	missed     []*FeedSub
+	err        error
+}
+
+// deliver sends the value until all channels except removeSub have been chosen. It
+// reports whether delivery was abandoned because done or f.quit was closed.
+//
+// If tier is false, cases and subs are f.sendCases and f.sendSubs, and 'cases' tracks
+// a prefix of sendCases. When a send succeeds, the corresponding case moves to the end
+// of 'cases' and it shrinks by one element.
+//
+// If tier is true, cases and subs are a copy of one priority tier preceded by the
+// removeSub case. Subscriptions using SkipUnderBackpressure are skipped if a higher
+// tier blocked.
+func (f *Feed) deliver(s *feedSend, cases caseList, subs subList[*FeedSub], tier bool) (abandoned bool) {
+	pressured := tier && !s.blockStart.IsZero()
 	for {
 		// Fast path: try sending without blocking before adding to the select set.
 		// This should usually succeed if subscribers are fast enough and have free
 		// buffer space.
 		for i := firstSubSendCase; i < len(cases); i++ {
-			if cases[i].Chan.TrySend(rvalue) {
-				nsent++
+			sent := cases[i].Chan.TrySend(s.value)
+			if sent || (pressured && subs[i].skippable) {
+				if sent {
+					if !s.blockStart.IsZero() {
+						subs[i].counters.waited(s.metrics, subs[i], s.blockStart)
+					}
+					s.nsent++
+				}
 				cases = cases.deactivate(i)
+				subs = subs.deactivate(i)
 				i--
 			}
 		}
 		if len(cases) == firstSubSendCase {
-			break
+			return false
+		}
+		if s.blockStart.IsZero() {
+			s.blockStart = time.Now()
+		}
+		// Select on all the receivers, waiting for them to unblock. The done and quit
+		// cases are appended to a copy because the tail of f.sendCases holds
+		// deactivated cases.
+		selCases := append(cases[:len(cases):len(cases)], s.doneCase, s.quitCase)
+		chosen, recv, _ := reflect.Select(selCases)
+		if chosen >= len(cases) /* <-done or <-f.quit */ {
+			if chosen > len(cases) {
+				s.err = ErrFeedClosed
+			}
+			for _, sub := range subs[firstSubSendCase:] {
+				sub.counters.waited(s.metrics, sub, s.blockStart)
+			}
+			s.missed = append(s.missed, subs[firstSubSendCase:]...)
+			return true
 		}
-		// Select on all the receivers, waiting for them to unblock.
-		chosen, recv, _ := reflect.Select(cases)
 		if chosen == 0 /* <-f.removeSub */ {
-			index := f.sendCases.find(recv.Interface())
+			removed := recv.Interface().(*FeedSub)
+			index := f.sendSubs.find(removed)
 			f.sendCases = f.sendCases.delete(index)
-			if index >= 0 && index < len(cases) {
-				// Shrink 'cases' too because the removed case was still active.
-				cases = f.sendCases[:len(cases)-1]
+			f.sendSubs = f.sendSubs.delete(index)
+			if !tier {
+				if index >= 0 && index < len(cases) {
+					// Shrink 'cases' too because the removed case was still active.
+					cases = f.sendCases[:len(cases)-1]
+					subs = f.sendSubs[:len(subs)-1]
+				}
+			} else if i := subs.find(removed); i != -1 {
+				cases = cases.delete(i)
+				subs = subs.delete(i)
 			}
 		} else {
+			subs[chosen].counters.waited(s.metrics, subs[chosen], s.blockStart)
 			cases = cases.deactivate(chosen)
-			nsent++
+			subs = subs.deactivate(chosen)
+			s.nsent++
 		}
 	}
+}
 
-	// Forget about the sent value and hand off the send lock.
-	for i := firstSubSendCase; i < len(f.sendCases); i++ {
-		f.sendCases[i].Send = reflect.Value{}
+// deliverTiers delivers to the subscriptions in f.sendCases, which is sorted by
+// descending priority, one tier of equal priority after the other. Working on copies
+// keeps f.sendCases sorted.
+func (f *Feed) deliverTiers(s *feedSend) {
+	var (
+		cases caseList
+		subs  subList[*FeedSub]
+	)
+	for start := firstSubSendCase; start < len(f.sendSubs); {
+		prio := f.sendSubs[start].priority
+		end := start
+		for end < len(f.sendSubs) && f.sendSubs[end].priority == prio {
+			end++
+		}
+		cases = append(append(cases[:0], f.sendCases[0]), f.sendCases[start:end]...)
+		subs = append(append(subs[:0], nil), f.sendSubs[start:end]...)
+		abandoned := f.deliver(s, cases, subs, true)
+
+		// Removals may have shifted f.sendSubs, find the next tier by priority.
+		start = firstSubSendCase
+		for start < len(f.sendSubs) && f.sendSubs[start].priority >= prio {
+			start++
+		}
+		if abandoned {
+			s.missed = append(s.missed, f.sendSubs[start:]...)
+			return
+		}
+	}
+}
+
+// byPriority sorts cases and their subscriptions by descending priority.
+type byPriority struct {
+	cases caseList
+	subs  subList[*FeedSub]
+}
+
+func (p byPriority) Len() int           { return len(p.subs) }
+func (p byPriority) Less(i, j int) bool { return p.subs[i].priority > p.subs[j].priority }
+func (p byPriority) Swap(i, j int) {
+	p.cases[i], p.cases[j] = p.cases[j], p.cases[i]
+	p.subs[i], p.subs[j] = p.subs[j], p.subs[i]
+}
+
+// Close ends all subscriptions, closing their Err() channels, and interrupts a Send in
+// progress. Afterwards Send delivers nothing, SendContext returns ErrFeedClosed and
+// new subscriptions fail with ErrFeedClosed. With CloseChannels, the subscribed
+// channels are closed too. Calling Close again has no effect.
+func (f *Feed) Close(opts ...CloseOption) {
+	f.once.Do(func() { f.init(nil) })
+	o := newCloseOptions(opts)
+
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.closed = true
+	close(f.quit)
+	subs := make([]*FeedSub, 0, len(f.subs))
+	for sub := range f.subs {
+		subs = append(subs, sub)
 	}
+	f.subs, f.inbox, f.inboxSubs, f.nonblocking = nil, nil, nil, nil
+	f.mu.Unlock()
+
+	// Wait for a Send in progress to notice f.quit.
+	<-f.sendLock
+	clear(f.sendCases[firstSubSendCase:])
+	clear(f.sendSubs[firstSubSendCase:])
+	f.sendCases = f.sendCases[:firstSubSendCase]
+	f.sendSubs = f.sendSubs[:firstSubSendCase]
 	f.sendLock <- struct{}{}
-	return nsent
+
+	closed := make(map[uintptr]bool)
+	for _, sub := range subs {
+		sub.errOnce.Do(func() {
+			if sub.ring != nil {
+				sub.ring.stop()
+			}
+			if ptr := sub.channel.Pointer(); o.closeChannels && !closed[ptr] {
+				closed[ptr] = true
+				sub.channel.Close()
+			}
+			close(sub.err)
+		})
+	}
+}
+
+// Stats returns a snapshot of the subscriptions and delivery counters of the feed. It
+// does not wait for a Send in progress, so it can be used to find the subscriber
+// stalling the feed.
+func (f *Feed) Stats() FeedStats {
+	f.mu.Lock()
+	stats := FeedStats{Inbox: len(f.inbox), NonBlocking: len(f.nonblocking)}
+	stats.Active = len(f.subs) - stats.Inbox - stats.NonBlocking
+	stats.Subscriptions = make([]SubscriptionStats, 0, len(f.subs))
+	for sub := range f.subs {
+		stats.Subscriptions = append(stats.Subscriptions, sub.stats())
+	}
+	f.mu.Unlock()
+	f.counters.fill(&stats)
+	return stats
+}
+
+// SetMetrics installs a hook receiving the measurements of future sends. nil removes
+// the hook.
+func (f *Feed) SetMetrics(metrics FeedMetrics) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metrics = metrics
 }
 
 type FeedSub struct {
-	feed    *Feed
-	channel reflect.Value
-	errOnce sync.Once
-	err     chan error
+	feed      *Feed
+	channel   reflect.Value
+	policy    DeliveryPolicy
+	priority  int                  // see WithPriority
+	skippable bool                 // see SkipUnderBackpressure
+	ring      *ring[reflect.Value] // only used by DropOldest
+	counters  subCounters
+	errOnce   sync.Once
+	err       chan error
 }
 
 func (sub *FeedSub) Unsubscribe() {
 	sub.errOnce.Do(func() {
 		sub.feed.remove(sub)
+		if sub.ring != nil {
+			sub.ring.stop()
+		}
 		close(sub.err)
 	})
 }
 
+// deliver hands value to a subscription with a non-blocking policy. It reports
+// whether the value was accepted and whether the subscription must be dropped.
+func (sub *FeedSub) deliver(value reflect.Value) (sent, drop bool) {
+	switch sub.policy {
+	case DropOldest:
+		sub.ring.push(value)
+		return true, false
+	case Disconnect:
+		if sub.channel.TrySend(value) {
+			return true, false
+		}
+		sub.err <- ErrSlowSubscriber
+		return false, true
+	default:
+		return sub.channel.TrySend(value), false
+	}
+}
+
+// forward sends a value queued by DropOldest, giving up when the subscription ends.
+func (sub *FeedSub) forward(value reflect.Value) bool {
+	chosen, _, _ := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: sub.channel, Send: value},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.ring.quit)},
+	})
+	return chosen == 0
+}
+
 func (sub *FeedSub) Err() <-chan error {
 	return sub.err
 }
+
+func (sub *FeedSub) stats() SubscriptionStats {
+	s := SubscriptionStats{
+		Subscription: sub,
+		Policy:       sub.policy,
+		QueueLen:     sub.channel.Len(),
+		QueueCap:     sub.channel.Cap(),
+		Blocked:      sub.counters.blocked.Load(),
+		Wait:         sub.counters.wait.snapshot(),
+	}
+	if sub.ring != nil {
+		s.QueueLen += sub.ring.len()
+		s.QueueCap += len(sub.ring.buf)
+	}
+	return s
+}
@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -287,12 +288,10 @@ func TestFeedUnsubscribeFromInbox(t *testing.T) {
 	}
 }
 
-func BenchmarkFeedSend1000(b *testing.B) {
-	var (
-		done  sync.WaitGroup
-		feed  Feed
-		nsubs = 1000
-	)
+// benchmarkSend measures Send to nsubs subscribers which keep reading from channels
+// with ample buffer space.
+func benchmarkSend(b *testing.B, nsubs int, subscribe func(chan int), send func(int) int) {
+	var done sync.WaitGroup
 	subscriber := func(ch <-chan int) {
 		for i := 0; i < b.N; i++ {
 			<-ch
@@ -302,18 +301,88 @@ func BenchmarkFeedSend1000(b *testing.B) {
 	done.Add(nsubs)
 	for i := 0; i < nsubs; i++ {
 		ch := make(chan int, 200)
-		feed.Subscribe(ch)
+		subscribe(ch)
 		go subscriber(ch)
 	}
 
 	// The actual benchmark.
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if feed.Send(i) != nsubs {
+		if send(i) != nsubs {
 			panic("wrong number of sends")
 		}
 	}
-
 	b.StopTimer()
 	done.Wait()
 }
+
+func BenchmarkFeedSend1000(b *testing.B) {
+	var feed Feed
+	benchmarkSend(b, 1000, func(ch chan int) { feed.Subscribe(ch) }, func(v int) int { return feed.Send(v) })
+}
+
+func TestFeedSendContext(t *testing.T) {
+	var (
+		feed    Feed
+		fast    = make(chan int, 1)
+		blocked = make(chan int)
+		_       = feed.Subscribe(fast)
+		sub     = feed.Subscribe(blocked)
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	nsent, missed, err := feed.SendContext(ctx, 1)
+	if nsent != 1 {
+		t.Errorf("SendContext delivered %d times, want 1", nsent)
+	}
+	if len(missed) != 1 || missed[0] != sub {
+		t.Errorf("missed %v, want the blocked subscription", missed)
+	}
+	if err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	<-fast
+
+	// The feed must still be usable after the abandoned send.
+	done := make(chan int)
+	go func() { done <- feed.Send(2) }()
+	if v := <-blocked; v != 2 {
+		t.Errorf("received %d, want 2", v)
+	}
+	<-fast
+	if nsent := <-done; nsent != 2 {
+		t.Errorf("send after SendContext delivered %d times, want 2", nsent)
+	}
+	sub.Unsubscribe()
+	nsent, missed, err = feed.SendContext(context.Background(), 3)
+	if nsent != 1 || missed != nil || err != nil {
+		t.Errorf("SendContext = %d, %v, %v, want 1, nil, nil", nsent, missed, err)
+	}
+}
+
+// benchmarkSendFastPath measures Send when no subscriber blocks: every subscriber has
+// room for the value, and the channels are drained outside of the timer.
+func benchmarkSendFastPath(b *testing.B, nsubs int, subscribe func(chan int), send func(int) int) {
+	chans := make([]chan int, nsubs)
+	for i := range chans {
+		chans[i] = make(chan int, 1)
+		subscribe(chans[i])
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if send(i) != len(chans) {
+			panic("wrong number of sends")
+		}
+		b.StopTimer()
+		for _, ch := range chans {
+			<-ch
+		}
+		b.StartTimer()
+	}
+}
+
+func BenchmarkFeedSendFastPath1000(b *testing.B) {
+	var feed Feed
+	benchmarkSendFastPath(b, 1000, func(ch chan int) { feed.Subscribe(ch) }, func(v int) int { return feed.Send(v) })
+}
@@ -1,58 +1,138 @@
 package event
 
 import (
+	"context"
 	"reflect"
 	"sync"
+	"time"
 )
 
 // FeedOf implements one-to-many subscriptions where the carrier of events is a channel.
 // Values sent to a Feed are delivered to all subscribed channels simultaneously.
 //
+// Unlike Feed, FeedOf delivers with typed channel operations and only falls back to
+// reflection while more than one subscriber is blocked.
+//
 // The zero value is ready to use.
 type FeedOf[T any] struct {
-	once      sync.Once     // ensures that init only runs once
-	sendLock  chan struct{} // sendLock has a one-element buffer and is empty when held.It protects sendCases.
-	removeSub chan chan<- T // interrupts Send
-	sendCases caseList      // the active set of select cases used by Send
+	once      sync.Once              // ensures that init only runs once
+	sendLock  chan struct{}          // sendLock has a one-element buffer and is empty when held.It protects sendSubs and sendCases.
+	removeSub chan *FeedOfSub[T]     // interrupts Send
+	sendSubs  subList[*FeedOfSub[T]] // the active set of subscriptions used by Send, sendSubs[0] is nil
+	sendCases caseList               // select cases for Send's slow path, only sendCases[0] is kept between sends
 
-	// The inbox holds newly subscribed channels until they are added to sendCases.
+	// The inbox holds newly subscribed channels until they are added to sendSubs.
 	mu    sync.Mutex
-	inbox caseList
+	inbox subList[*FeedOfSub[T]]
+
+	closed bool          // set by Close, protected by mu
+	quit   chan struct{} // closed by Close, interrupts Send
+
+	// nonblocking holds subscriptions whose policy never blocks Send. It is
+	// protected by mu and served by Send without using sendSubs.
+	nonblocking []*FeedOfSub[T]
+
+	// history holds the last 'replay' values sent, see SetReplay. Protected by mu.
+	replay  int
+	history []T
+
+	// subs holds every subscription for Stats. It is protected by mu, like the
+	// hook set by SetMetrics.
+	subs     map[*FeedOfSub[T]]struct{}
+	metrics  FeedMetrics
+	counters feedCounters
 }
 
 func (f *FeedOf[T]) init() {
-	f.removeSub = make(chan chan<- T)
+	f.removeSub = make(chan *FeedOfSub[T])
+	f.quit = make(chan struct{})
 	f.sendLock = make(chan struct{}, 1)
 	f.sendLock <- struct{}{}
 	f.sendCases = caseList{{Chan: reflect.ValueOf(f.removeSub), Dir: reflect.SelectRecv}}
+	f.sendSubs = subList[*FeedOfSub[T]]{nil}
 }
 
 // Subscribe adds a channel to the feed. Future sends will be delivered on the channel
 // until the subscription is canceled.
 //
 // The channel should have ample buffer space to avoid blocking other subscribers. Slow
-// subscribers are not dropped.
-func (f *FeedOf[T]) Subscribe(channel chan<- T) Subscription {
+// subscribers are not dropped unless a DeliveryPolicy other than Block is given with
+// WithPolicy.
+//
+// If replay is enabled, values sent while the backlog is delivered are buffered in
+// an internal channel, see SetReplay.
+//
+// If the feed is closed, the subscription fails immediately with ErrFeedClosed.
+func (f *FeedOf[T]) Subscribe(channel chan<- T, opts ...SubscribeOption) Subscription {
+	return f.subscribe(channel, nil, opts)
+}
+
+// SubscribeFunc is like Subscribe, but only values for which filter returns true are
+// delivered on the channel. Rejected values do not count towards the result of Send.
+//
+// The filter runs inside Send and must be fast. It must not call back into the feed.
+func (f *FeedOf[T]) SubscribeFunc(filter func(T) bool, channel chan<- T, opts ...SubscribeOption) Subscription {
+	return f.subscribe(channel, filter, opts)
+}
+
+func (f *FeedOf[T]) subscribe(channel chan<- T, filter func(T) bool, opts []SubscribeOption) *FeedOfSub[T] {
 	f.once.Do(f.init)
 
-	chanval := reflect.ValueOf(channel)
-	sub := &FeedOfSub[T]{feed: f, channel: channel, err: make(chan error, 1)}
+	o := newSubOptions(opts)
+	sub := &FeedOfSub[T]{feed: f, channel: channel, out: channel, filter: filter, policy: o.policy, err: make(chan error, 1)}
 
-	// Add the select case to the inbox.
-	// The next Send will add it to f.sendCases.
+	// Add the subscription to the inbox.
+	// The next Send will add it to f.sendSubs.
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	cas := reflect.SelectCase{Dir: reflect.SelectSend, Chan: chanval}
-	f.inbox = append(f.inbox, cas)
+	if f.closed {
+		sub.err <- ErrFeedClosed
+		return sub
+	}
+	// Taking the replay snapshot under the same lock as adding the subscription
+	// ensures that every value sent is either replayed or delivered live, never
+	// both or neither.
+	backlog := f.backlog(filter)
+	if len(backlog) > 0 && sub.policy != DropOldest {
+		sub.startReplay(backlog)
+	}
+	if f.subs == nil {
+		f.subs = make(map[*FeedOfSub[T]]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	if sub.policy != Block {
+		if sub.policy == DropOldest {
+			sub.ring = newRing[T](o.ringSize)
+			for _, v := range backlog {
+				sub.ring.push(v)
+			}
+			go sub.ring.run(sub.forward)
+		}
+		f.nonblocking = append(f.nonblocking, sub)
+		return sub
+	}
+	sub.chanval = reflect.ValueOf(sub.channel)
+	f.inbox = append(f.inbox, sub)
 	return sub
 }
 
 func (f *FeedOf[T]) remove(sub Subscription) {
 	// Delete from inbox first, which covers channels
-	// that have not been added to f.sendCases yet.
+	// that have not been added to f.sendSubs yet.
 	feedOfSub := sub.(*FeedOfSub[T])
 	f.mu.Lock()
-	index := f.inbox.find(feedOfSub.channel)
+	if f.closed {
+		// Close ends all subscriptions.
+		f.mu.Unlock()
+		return
+	}
+	delete(f.subs, feedOfSub)
+	if feedOfSub.policy != Block {
+		f.removeNonblocking(feedOfSub)
+		f.mu.Unlock()
+		return
+	}
+	index := f.inbox.find(feedOfSub)
 	if index != -1 {
 		f.inbox = f.inbox.delete(index)
 		f.mu.Unlock()
@@ -61,89 +141,374 @@ func (f *FeedOf[T]) remove(sub Subscription) {
 	f.mu.Unlock()
 
 	select {
-	case f.removeSub <- feedOfSub.channel:
-		// Send will remove the channel from f.sendCases.
+	case f.removeSub <- feedOfSub:
+		// Send will remove the channel from f.sendSubs.
 	case <-f.sendLock:
 		// No Send is in progress, delete the channel now that we have the send lock.
-		f.sendCases = f.sendCases.delete(f.sendCases.find(feedOfSub.channel))
+		// It is gone already if Close ran in the meantime.
+		if index := f.sendSubs.find(feedOfSub); index != -1 {
+			f.sendSubs = f.sendSubs.delete(index)
+		}
 		f.sendLock <- struct{}{}
 	}
 }
 
+// removeNonblocking deletes sub from f.nonblocking. It must be called with f.mu held.
+func (f *FeedOf[T]) removeNonblocking(sub *FeedOfSub[T]) {
+	for i, s := range f.nonblocking {
+		if s == sub {
+			f.nonblocking = append(f.nonblocking[:i:i], f.nonblocking[i+1:]...)
+			return
+		}
+	}
+}
+
 // Send delivers to all subscribed channels simultaneously.
-// It returns the number of subscribers that the value was sent to.
+// It returns the number of subscribers that the value was sent to, which is zero
+// once the feed is closed.
 func (f *FeedOf[T]) Send(value T) (nsent int) {
-	rvalue := reflect.ValueOf(value)
+	nsent, _, _ = f.send(nil, value)
+	return nsent
+}
 
+// SendContext delivers to all subscribed channels like Send, but stops waiting for
+// blocked subscribers when ctx is done. It returns the number of subscribers that the
+// value was sent to and, if ctx expired first, the subscriptions which did not receive
+// the value along with ctx.Err(). Subscriptions with a non-blocking DeliveryPolicy
+// never cause SendContext to wait and are not reported as missed.
+//
+// If the feed is closed before or during the call, the error is ErrFeedClosed.
+func (f *FeedOf[T]) SendContext(ctx context.Context, value T) (nsent int, missed []Subscription, err error) {
+	nsent, subs, err := f.send(ctx.Done(), value)
+	if len(subs) == 0 {
+		return nsent, nil, err
+	}
+	missed = make([]Subscription, len(subs))
+	for i, sub := range subs {
+		missed[i] = sub
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return nsent, missed, err
+}
+
+// send implements Send and SendContext. Delivery is abandoned when done is closed or
+// the feed is closed, in which case the subscriptions still waiting for the value are
+// returned.
+func (f *FeedOf[T]) send(done <-chan struct{}, value T) (nsent int, missed []*FeedOfSub[T], err error) {
+	start := time.Now()
 	f.once.Do(f.init)
 	<-f.sendLock
 
-	// Add new cases from the inbox after taking the send lock.
+	// Add new subscriptions from the inbox after taking the send lock.
 	f.mu.Lock()
-	f.sendCases = append(f.sendCases, f.inbox...)
+	if f.closed {
+		f.mu.Unlock()
+		f.sendLock <- struct{}{}
+		return 0, nil, ErrFeedClosed
+	}
+	metrics := f.metrics
+	f.sendSubs = append(f.sendSubs, f.inbox...)
 	f.inbox = nil
+	// Serve subscriptions that never block while holding mu, so that Unsubscribe
+	// cannot return while a delivery to them is in progress.
+	for i := 0; i < len(f.nonblocking); i++ {
+		sub := f.nonblocking[i]
+		if sub.filter != nil && !sub.filter(value) {
+			continue
+		}
+		sent, drop := sub.deliver(value)
+		if sent {
+			nsent++
+		}
+		if drop {
+			f.removeNonblocking(sub)
+			delete(f.subs, sub)
+			i--
+		}
+	}
+	f.record(value)
 	f.mu.Unlock()
 
-	// Set the sent value on all channels.
-	for i := firstSubSendCase; i < len(f.sendCases); i++ {
-		f.sendCases[i].Send = rvalue
+	// Send until all subscriptions have been chosen. 'subs' tracks a prefix of sendSubs.
+	// When a send succeeds, the corresponding subscription moves to the end of 'subs'
+	// and it shrinks by one element.
+	//
+	// 'cases' is only built once more than one subscriber blocks. From then on it
+	// mirrors 'subs' index by index and is shrunk along with it.
+	subs := f.sendSubs
+	var (
+		cases        caseList
+		rdone, rquit reflect.Value
+		blockStart   time.Time // set once a subscriber blocks
+	)
+	// Skip subscriptions whose filter rejects the value.
+	for i := firstSubSendCase; i < len(subs); i++ {
+		if subs[i].filter != nil && !subs[i].filter(value) {
+			subs = subs.deactivate(i)
+			i--
+		}
 	}
-
-	// Send until all channels except removeSub have been chosen. 'cases' tracks a prefix
-	// of sendCases. When a send succeeds, the corresponding case moves to the end of
-	// 'cases' and it shrinks by one element.
-	cases := f.sendCases
 	for {
-		// Fast path: try sending without blocking before adding to the select set.
+		// Fast path: try sending without blocking using typed channel operations.
 		// This should usually succeed if subscribers are fast enough and have free
 		// buffer space.
-		for i := firstSubSendCase; i < len(cases); i++ {
-			if cases[i].Chan.TrySend(rvalue) {
+		for i := firstSubSendCase; i < len(subs); i++ {
+			select {
+			case subs[i].channel <- value:
+				if !blockStart.IsZero() {
+					subs[i].counters.waited(metrics, subs[i], blockStart)
+				}
 				nsent++
-				cases = cases.deactivate(i)
+				subs = subs.deactivate(i)
+				if cases != nil {
+					cases = cases.deactivate(i)
+				}
 				i--
+			default:
+			}
+		}
+		if len(subs) == firstSubSendCase {
+			break
+		}
+		if blockStart.IsZero() {
+			blockStart = time.Now()
+		}
+
+		var (
+			chosen  int
+			removed *FeedOfSub[T]
+		)
+		if len(subs) == firstSubSendCase+1 {
+			// A single blocked subscriber can still be waited on without reflection.
+			select {
+			case subs[firstSubSendCase].channel <- value:
+				chosen = firstSubSendCase
+			case removed = <-f.removeSub:
+				chosen = 0
+			case <-done:
+				chosen = len(subs)
+			case <-f.quit:
+				chosen = len(subs) + 1
+			}
+		} else {
+			if cases == nil {
+				rvalue := reflect.ValueOf(value)
+				for _, sub := range subs[firstSubSendCase:] {
+					f.sendCases = append(f.sendCases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: sub.chanval, Send: rvalue})
+				}
+				cases = f.sendCases
+			}
+			// Select on all the receivers, waiting for them to unblock. The done and
+			// quit cases are appended to a copy because the tail of 'cases' holds
+			// deactivated cases. A nil done channel leaves rdone invalid, which makes
+			// Select ignore its case.
+			if !rquit.IsValid() {
+				rquit = reflect.ValueOf(f.quit)
+				if done != nil {
+					rdone = reflect.ValueOf(done)
+				}
+			}
+			selCases := append(cases[:len(cases):len(cases)],
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: rdone},
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: rquit},
+			)
+			var recv reflect.Value
+			chosen, recv, _ = reflect.Select(selCases)
+			if chosen == 0 {
+				removed = recv.Interface().(*FeedOfSub[T])
 			}
 		}
-		if len(cases) == firstSubSendCase {
+
+		if chosen >= len(subs) /* <-done or <-f.quit */ {
+			if chosen > len(subs) {
+				err = ErrFeedClosed
+			}
+			for _, sub := range subs[firstSubSendCase:] {
+				sub.counters.waited(metrics, sub, blockStart)
+			}
+			missed = append(missed, subs[firstSubSendCase:]...)
 			break
 		}
-		// Select on all the receivers, waiting for them to unblock.
-		chosen, recv, _ := reflect.Select(cases)
 		if chosen == 0 /* <-f.removeSub */ {
-			index := f.sendCases.find(recv.Interface())
-			f.sendCases = f.sendCases.delete(index)
-			if index >= 0 && index < len(cases) {
-				// Shrink 'cases' too because the removed case was still active.
-				cases = f.sendCases[:len(cases)-1]
+			index := f.sendSubs.find(removed)
+			f.sendSubs = f.sendSubs.delete(index)
+			if index >= 0 && index < len(subs) {
+				// Shrink 'subs' too because the removed subscription was still active.
+				subs = f.sendSubs[:len(subs)-1]
+				if cases != nil {
+					cases = cases.delete(index)
+				}
 			}
 		} else {
-			cases = cases.deactivate(chosen)
+			subs[chosen].counters.waited(metrics, subs[chosen], blockStart)
+			subs = subs.deactivate(chosen)
+			if cases != nil {
+				cases = cases.deactivate(chosen)
+			}
 			nsent++
 		}
 	}
 
 	// Forget about the sent value and hand off the send lock.
-	for i := firstSubSendCase; i < len(f.sendCases); i++ {
-		f.sendCases[i].Send = reflect.Value{}
+	if len(f.sendCases) > firstSubSendCase {
+		clear(f.sendCases[firstSubSendCase:])
+		f.sendCases = f.sendCases[:firstSubSendCase]
 	}
 	f.sendLock <- struct{}{}
-	return nsent
+	f.counters.sendDone(metrics, nsent, start, !blockStart.IsZero())
+	return nsent, missed, err
+}
+
+// Close ends all subscriptions, closing their Err() channels, and interrupts a Send in
+// progress. Afterwards Send delivers nothing, SendContext returns ErrFeedClosed and
+// new subscriptions fail with ErrFeedClosed. With CloseChannels, the subscribed
+// channels are closed too. Calling Close again has no effect.
+func (f *FeedOf[T]) Close(opts ...CloseOption) {
+	f.once.Do(f.init)
+	o := newCloseOptions(opts)
+
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.closed = true
+	close(f.quit)
+	subs := make([]*FeedOfSub[T], 0, len(f.subs))
+	for sub := range f.subs {
+		subs = append(subs, sub)
+	}
+	f.subs, f.inbox, f.nonblocking, f.history = nil, nil, nil, nil
+	f.mu.Unlock()
+
+	// Wait for a Send in progress to notice f.quit.
+	<-f.sendLock
+	clear(f.sendSubs[firstSubSendCase:])
+	f.sendSubs = f.sendSubs[:firstSubSendCase]
+	f.sendLock <- struct{}{}
+
+	closed := make(map[chan<- T]bool)
+	for _, sub := range subs {
+		sub.errOnce.Do(func() {
+			sub.stop()
+			if o.closeChannels && !closed[sub.out] {
+				closed[sub.out] = true
+				close(sub.out)
+			}
+			close(sub.err)
+		})
+	}
+}
+
+// Stats returns a snapshot of the subscriptions and delivery counters of the feed. It
+// does not wait for a Send in progress, so it can be used to find the subscriber
+// stalling the feed.
+func (f *FeedOf[T]) Stats() FeedStats {
+	f.mu.Lock()
+	stats := FeedStats{Inbox: len(f.inbox), NonBlocking: len(f.nonblocking)}
+	stats.Active = len(f.subs) - stats.Inbox - stats.NonBlocking
+	stats.Subscriptions = make([]SubscriptionStats, 0, len(f.subs))
+	for sub := range f.subs {
+		stats.Subscriptions = append(stats.Subscriptions, sub.stats())
+	}
+	f.mu.Unlock()
+	f.counters.fill(&stats)
+	return stats
+}
+
+// SetMetrics installs a hook receiving the measurements of future sends. nil removes
+// the hook.
+func (f *FeedOf[T]) SetMetrics(metrics FeedMetrics) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metrics = metrics
 }
 
 type FeedOfSub[T any] struct {
-	feed    *FeedOf[T]
-	channel chan<- T
-	errOnce sync.Once
-	err     chan error
+	feed     *FeedOf[T]
+	channel  chan<- T      // where the feed delivers, differs from out while replaying
+	out      chan<- T      // the subscribed channel
+	chanval  reflect.Value // reflect.ValueOf(channel), used by Send's slow path
+	filter   func(T) bool  // set by SubscribeFunc
+	policy   DeliveryPolicy
+	ring     *ring[T]  // only used by DropOldest
+	replay   *replayer // only used while replaying, see SetReplay
+	counters subCounters
+	errOnce  sync.Once
+	err      chan error
 }
 
 func (sub *FeedOfSub[T]) Unsubscribe() {
 	sub.errOnce.Do(func() {
 		sub.feed.remove(sub)
+		sub.stop()
 		close(sub.err)
 	})
 }
 
+// stop ends the helper goroutines of the subscription and waits for them to exit.
+func (sub *FeedOfSub[T]) stop() {
+	if sub.ring != nil {
+		sub.ring.stop()
+	}
+	if sub.replay != nil {
+		sub.replay.stop()
+	}
+}
+
+// deliver hands value to a subscription with a non-blocking policy. It reports
+// whether the value was accepted and whether the subscription must be dropped.
+func (sub *FeedOfSub[T]) deliver(value T) (sent, drop bool) {
+	switch sub.policy {
+	case DropOldest:
+		sub.ring.push(value)
+		return true, false
+	case Disconnect:
+		select {
+		case sub.channel <- value:
+			return true, false
+		default:
+			sub.err <- ErrSlowSubscriber
+			return false, true
+		}
+	default:
+		select {
+		case sub.channel <- value:
+			return true, false
+		default:
+			return false, false
+		}
+	}
+}
+
+// forward sends a value queued by DropOldest, giving up when the subscription ends.
+func (sub *FeedOfSub[T]) forward(value T) bool {
+	select {
+	case sub.channel <- value:
+		return true
+	case <-sub.ring.quit:
+		return false
+	}
+}
+
 func (sub *FeedOfSub[T]) Err() <-chan error {
 	return sub.err
 }
+
+func (sub *FeedOfSub[T]) stats() SubscriptionStats {
+	s := SubscriptionStats{
+		Subscription: sub,
+		Policy:       sub.policy,
+		QueueLen:     len(sub.channel),
+		QueueCap:     cap(sub.channel),
+		Blocked:      sub.counters.blocked.Load(),
+		Wait:         sub.counters.wait.snapshot(),
+	}
+	if sub.ring != nil {
+		s.QueueLen += sub.ring.len()
+		s.QueueCap += len(sub.ring.buf)
+	}
+	return s
+}
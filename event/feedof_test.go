@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -232,32 +233,82 @@ func TestFeedOfUnsubscribeFromInbox(t *testing.T) {
 }
 
 func BenchmarkFeedOfSend1000(b *testing.B) {
+	var feed FeedOf[int]
+	benchmarkSend(b, 1000, func(ch chan int) { feed.Subscribe(ch) }, feed.Send)
+}
+
+func TestFeedOfSendContext(t *testing.T) {
 	var (
-		done  sync.WaitGroup
-		feed  FeedOf[int]
-		nsubs = 1000
+		feed    FeedOf[int]
+		fast    = make(chan int, 1)
+		blocked = make(chan int)
+		_       = feed.Subscribe(fast)
+		sub     = feed.Subscribe(blocked)
 	)
-	subscriber := func(ch <-chan int) {
-		for i := 0; i < b.N; i++ {
-			<-ch
-		}
-		done.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	nsent, missed, err := feed.SendContext(ctx, 1)
+	if nsent != 1 {
+		t.Errorf("SendContext delivered %d times, want 1", nsent)
+	}
+	if len(missed) != 1 || missed[0] != sub {
+		t.Errorf("missed %v, want the blocked subscription", missed)
 	}
-	done.Add(nsubs)
-	for i := 0; i < nsubs; i++ {
-		ch := make(chan int, 200)
-		feed.Subscribe(ch)
-		go subscriber(ch)
+	if err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
 	}
+	<-fast
 
-	// The actual benchmark.
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		if feed.Send(i) != nsubs {
-			panic("wrong number of sends")
-		}
+	// The feed must still be usable after the abandoned send.
+	done := make(chan int)
+	go func() { done <- feed.Send(2) }()
+	if v := <-blocked; v != 2 {
+		t.Errorf("received %d, want 2", v)
+	}
+	<-fast
+	if nsent := <-done; nsent != 2 {
+		t.Errorf("send after SendContext delivered %d times, want 2", nsent)
+	}
+	sub.Unsubscribe()
+	nsent, missed, err = feed.SendContext(context.Background(), 3)
+	if nsent != 1 || missed != nil || err != nil {
+		t.Errorf("SendContext = %d, %v, %v, want 1, nil, nil", nsent, missed, err)
 	}
+}
 
-	b.StopTimer()
-	done.Wait()
+// BenchmarkFeedOfSendFastPath1000 measures Send when no subscriber blocks, which
+// FeedOf serves without reflection. Compare with BenchmarkFeedSendFastPath1000.
+func BenchmarkFeedOfSendFastPath1000(b *testing.B) {
+	var feed FeedOf[int]
+	benchmarkSendFastPath(b, 1000, func(ch chan int) { feed.Subscribe(ch) }, feed.Send)
+}
+
+func TestFeedOfSubscribeFunc(t *testing.T) {
+	var (
+		feed  FeedOf[int]
+		even  = make(chan int, 10)
+		odd   = make(chan int, 10)
+		sub1  = feed.SubscribeFunc(func(v int) bool { return v%2 == 0 }, even)
+		sub2  = feed.SubscribeFunc(func(v int) bool { return v%2 == 1 }, odd, WithPolicy(DropNewest))
+		total int
+	)
+	defer sub1.Unsubscribe()
+	defer sub2.Unsubscribe()
+
+	for i := 0; i < 6; i++ {
+		total += feed.Send(i)
+	}
+	if total != 6 {
+		t.Errorf("sends delivered %d times, want 6", total)
+	}
+	for _, want := range []int{0, 2, 4} {
+		if v := <-even; v != want {
+			t.Errorf("even received %d, want %d", v, want)
+		}
+	}
+	for _, want := range []int{1, 3, 5} {
+		if v := <-odd; v != want {
+			t.Errorf("odd received %d, want %d", v, want)
+		}
+	}
 }
@@ -0,0 +1,67 @@
+package event
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// HandlerPanicError is delivered on Subscription.Err() when the handler of a
+// subscription created by SubscribeHandler panics.
+type HandlerPanicError struct {
+	Value interface{} // the value passed to panic
+	Stack []byte      // stack trace of the panicking handler
+}
+
+func (e *HandlerPanicError) Error() string {
+	return fmt.Sprintf("event: handler panic: %v", e.Value)
+}
+
+// SubscribeHandler subscribes a callback to the feed instead of a channel. Values sent
+// on the feed are queued in an internal buffer and passed to handler by a pool of
+// worker goroutines owned by the subscription. The options apply to the internal
+// channel subscription, so e.g. WithPolicy(DropNewest) drops values while the buffer
+// is full instead of blocking Send.
+//
+// By default the pool has a single worker and handler is called for one value at a
+// time, in the order the values were sent. With WithConcurrency(n), up to n calls run
+// at the same time and no ordering is guaranteed.
+//
+// If handler panics, the panic is recovered and reported as a *HandlerPanicError on
+// the subscription's error channel, and the subscription ends. Unsubscribe waits for
+// running handler calls to return, so it must not be called from within handler.
+func (f *FeedOf[T]) SubscribeHandler(handler func(T), opts ...SubscribeOption) Subscription {
+	o := newSubOptions(opts)
+	return newAdapter(f, opts, func(sub *adapterSub, in <-chan T) {
+		call := func(v T) (ok bool) {
+			defer func() {
+				if r := recover(); r != nil {
+					sub.fail(&HandlerPanicError{Value: r, Stack: debug.Stack()})
+					ok = false
+				}
+			}()
+			handler(v)
+			return true
+		}
+		worker := func() {
+			for {
+				select {
+				case v := <-in:
+					if !call(v) {
+						return
+					}
+				case <-sub.quit:
+					return
+				}
+			}
+		}
+
+		sub.wg.Add(o.concurrency - 1)
+		for i := 1; i < o.concurrency; i++ {
+			go func() {
+				defer sub.wg.Done()
+				worker()
+			}()
+		}
+		worker()
+	})
+}
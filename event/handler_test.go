@@ -0,0 +1,100 @@
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubscribeHandlerOrder(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		got  []int
+		done = make(chan struct{})
+		n    = 1000
+	)
+	sub := feed.SubscribeHandler(func(v int) {
+		got = append(got, v)
+		if len(got) == n {
+			close(done)
+		}
+	})
+	defer sub.Unsubscribe()
+
+	for i := 0; i < n; i++ {
+		if nsent := feed.Send(i); nsent != 1 {
+			t.Fatalf("send %d delivered %d times, want 1", i, nsent)
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler not called for all values")
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("handler call %d got %d, want %d", i, v, i)
+		}
+	}
+}
+
+func TestSubscribeHandlerConcurrency(t *testing.T) {
+	const workers = 4
+	var (
+		feed    FeedOf[int]
+		running atomic.Int32
+		peak    atomic.Int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	sub := feed.SubscribeHandler(func(int) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+		wg.Done()
+	}, WithConcurrency(workers))
+	defer sub.Unsubscribe()
+
+	wg.Add(2 * workers)
+	for i := 0; i < 2*workers; i++ {
+		feed.Send(i)
+	}
+	waitFor(t, func() bool { return running.Load() == workers })
+	close(release)
+	wg.Wait()
+	if p := peak.Load(); p != workers {
+		t.Errorf("peak concurrency %d, want %d", p, workers)
+	}
+}
+
+func TestSubscribeHandlerPanic(t *testing.T) {
+	var feed FeedOf[int]
+	sub := feed.SubscribeHandler(func(v int) {
+		if v == 2 {
+			panic("boom")
+		}
+	})
+	defer sub.Unsubscribe()
+
+	for i := 0; i < 3; i++ {
+		feed.Send(i)
+	}
+	select {
+	case err := <-sub.Err():
+		perr, ok := err.(*HandlerPanicError)
+		if !ok || perr.Value != "boom" || len(perr.Stack) == 0 {
+			t.Errorf("got error %v, want handler panic error", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("panic not reported")
+	}
+	// The failed subscription no longer receives values.
+	waitFor(t, func() bool { return feed.Send(3) == 0 })
+}
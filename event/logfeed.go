@@ -0,0 +1,569 @@
+package event
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLogClosed is returned by LogFeed operations after Close and delivered on the Err()
+// channel of its subscriptions.
+var ErrLogClosed = errors.New("event: log feed closed")
+
+const (
+	defaultSegmentSize = 16 << 20
+	segmentExt         = ".log"
+	offsetsFile        = "offsets.json"
+)
+
+// LogRecord is an event stored in a LogFeed.
+type LogRecord[T any] struct {
+	Offset uint64    `json:"offset"`
+	Time   time.Time `json:"time"`
+	Value  T         `json:"value"`
+}
+
+// LogOption configures a LogFeed when it is opened.
+type LogOption func(*logOptions)
+
+type logOptions struct {
+	segmentSize int64
+	maxSize     int64
+	maxAge      time.Duration
+	now         func() time.Time
+}
+
+// WithSegmentSize sets the size in bytes after which the log starts a new segment file.
+func WithSegmentSize(n int64) LogOption {
+	return func(o *logOptions) {
+		if n > 0 {
+			o.segmentSize = n
+		}
+	}
+}
+
+// WithRetention makes the log delete its oldest segments once the total size exceeds
+// maxSize bytes or their last record is older than maxAge. Zero disables a limit.
+func WithRetention(maxSize int64, maxAge time.Duration) LogOption {
+	return func(o *logOptions) {
+		o.maxSize = maxSize
+		o.maxAge = maxAge
+	}
+}
+
+// WithLogClock sets the clock used to timestamp records and to apply the retention
+// age. It defaults to time.Now.
+func WithLogClock(now func() time.Time) LogOption {
+	return func(o *logOptions) {
+		o.now = now
+	}
+}
+
+type logSegment struct {
+	base uint64 // offset of the first record
+	path string
+	size int64
+	last time.Time // time of the last record, the file modification time after reopening
+}
+
+// LogFeed is a feed which appends every event to a segmented log on disk before
+// delivering it. Subscribers may start at any offset or time still held by the log
+// and catch up from disk before receiving live events, so a consumer which restarts
+// can resume where it left off using Commit and SubscribeConsumer.
+//
+// Records are stored as JSON lines, one file per segment, and every append is synced
+// to disk. Old segments are deleted according to WithRetention when a segment is full
+// or Compact is called.
+type LogFeed[T any] struct {
+	dir       string
+	opts      logOptions
+	quit      chan struct{} // closed by Close
+	closeOnce sync.Once
+
+	// sendMu orders the live delivery of appends. It is taken before mu and held
+	// while Append waits for the subscribers, so that they may call back into the log.
+	sendMu sync.Mutex
+
+	// mu protects the segments. A subscriber catching up switches to live delivery
+	// under mu, so it receives every record appended afterwards.
+	mu       sync.Mutex
+	segments []logSegment // oldest first, the last segment is appended to
+	file     *os.File
+	next     uint64 // offset of the next record
+	closed   bool
+	feed     FeedOf[LogRecord[T]]
+
+	offMu   sync.Mutex
+	offsets map[string]uint64 // committed consumer offsets
+}
+
+// OpenLogFeed opens or creates the log in dir. A record torn by a crash at the end of
+// the log is discarded.
+func OpenLogFeed[T any](dir string, opts ...LogOption) (*LogFeed[T], error) {
+	o := logOptions{segmentSize: defaultSegmentSize, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f := &LogFeed[T]{dir: dir, opts: o, quit: make(chan struct{}), offsets: make(map[string]uint64)}
+	if err := f.loadSegments(); err != nil {
+		return nil, err
+	}
+	if err := f.loadOffsets(); err != nil {
+		f.file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *LogFeed[T]) segmentPath(base uint64) string {
+	return filepath.Join(f.dir, fmt.Sprintf("%020d%s", base, segmentExt))
+}
+
+func (f *LogFeed[T]) loadSegments() error {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		f.segments = append(f.segments, logSegment{
+			base: base,
+			path: filepath.Join(f.dir, name),
+			size: info.Size(),
+			last: info.ModTime(),
+		})
+	}
+	sort.Slice(f.segments, func(i, j int) bool { return f.segments[i].base < f.segments[j].base })
+	if len(f.segments) == 0 {
+		return f.createSegment(0)
+	}
+	return f.recoverActive()
+}
+
+// recoverActive scans the last segment to find the next offset, truncating a torn
+// record, and opens it for appending.
+func (f *LogFeed[T]) recoverActive() error {
+	seg := &f.segments[len(f.segments)-1]
+	file, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	f.next = seg.base
+	r := bufio.NewReader(file)
+	var size int64
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var rec LogRecord[T]
+		if err = json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("event: log %s line %d: %w", seg.path, line, err)
+		}
+		size += int64(len(data))
+		f.next = rec.Offset + 1
+		seg.last = rec.Time
+	}
+	if size != seg.size {
+		if err = os.Truncate(seg.path, size); err != nil {
+			return err
+		}
+		seg.size = size
+	}
+	f.file, err = os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	return err
+}
+
+// createSegment starts a new segment whose first record has the given offset.
+func (f *LogFeed[T]) createSegment(base uint64) error {
+	path := f.segmentPath(base)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+	f.next = base
+	f.segments = append(f.segments, logSegment{base: base, path: path, last: f.opts.now()})
+	return nil
+}
+
+// Append stores value in the log and delivers it to the subscribers. It returns the
+// offset of the new record.
+//
+// Like Feed.Send, Append waits until every live subscriber has accepted the record.
+// Other appends wait meanwhile, but the rest of the log stays usable.
+func (f *LogFeed[T]) Append(value T) (uint64, error) {
+	f.sendMu.Lock()
+	defer f.sendMu.Unlock()
+	rec, err := f.write(value)
+	if err != nil {
+		return 0, err
+	}
+	f.feed.Send(rec)
+	return rec.Offset, nil
+}
+
+// write stores value in the log and returns its record.
+func (f *LogFeed[T]) write(value T) (LogRecord[T], error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return LogRecord[T]{}, ErrLogClosed
+	}
+	rec := LogRecord[T]{Offset: f.next, Time: f.opts.now(), Value: value}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return LogRecord[T]{}, err
+	}
+	data = append(data, '\n')
+
+	if seg := f.segments[len(f.segments)-1]; seg.size > 0 && seg.size+int64(len(data)) > f.opts.segmentSize {
+		if err = f.createSegment(f.next); err != nil {
+			return LogRecord[T]{}, err
+		}
+		if err = f.compactLocked(); err != nil {
+			return LogRecord[T]{}, err
+		}
+	}
+	if _, err = f.file.Write(data); err != nil {
+		return LogRecord[T]{}, err
+	}
+	if err = f.file.Sync(); err != nil {
+		return LogRecord[T]{}, err
+	}
+	seg := &f.segments[len(f.segments)-1]
+	seg.size += int64(len(data))
+	seg.last = rec.Time
+	f.next++
+	return rec, nil
+}
+
+// Offsets returns the offset of the oldest record held by the log and the offset the
+// next record will get.
+func (f *LogFeed[T]) Offsets() (first, next uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.segments[0].base, f.next
+}
+
+// Compact deletes the oldest segments exceeding the retention set by WithRetention.
+// The segment being appended to is never deleted.
+func (f *LogFeed[T]) Compact() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrLogClosed
+	}
+	return f.compactLocked()
+}
+
+func (f *LogFeed[T]) compactLocked() error {
+	var total int64
+	for _, seg := range f.segments {
+		total += seg.size
+	}
+	now := f.opts.now()
+	for len(f.segments) > 1 {
+		seg := f.segments[0]
+		overSize := f.opts.maxSize > 0 && total > f.opts.maxSize
+		overAge := f.opts.maxAge > 0 && now.Sub(seg.last) > f.opts.maxAge
+		if !overSize && !overAge {
+			break
+		}
+		if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		total -= seg.size
+		f.segments = f.segments[1:]
+	}
+	return nil
+}
+
+// Close closes the log and ends all subscriptions with ErrLogClosed.
+func (f *LogFeed[T]) Close() error {
+	// Ending the subscriptions first unblocks an Append waiting for a subscriber.
+	f.closeOnce.Do(func() { close(f.quit) })
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	return f.file.Close()
+}
+
+// SubscribeFrom delivers the records starting at offset on channel, first reading
+// them from disk and then following live appends. Records already deleted by
+// retention are skipped.
+func (f *LogFeed[T]) SubscribeFrom(offset uint64, channel chan<- LogRecord[T]) Subscription {
+	return f.subscribe(func() (uint64, error) { return offset, nil }, channel)
+}
+
+// SubscribeSince is like SubscribeFrom, starting at the first record stored at or
+// after t.
+func (f *LogFeed[T]) SubscribeSince(t time.Time, channel chan<- LogRecord[T]) Subscription {
+	return f.subscribe(func() (uint64, error) { return f.OffsetAt(t) }, channel)
+}
+
+// SubscribeConsumer is like SubscribeFrom, starting at the offset last committed by
+// consumer, or at the oldest record if it never committed.
+func (f *LogFeed[T]) SubscribeConsumer(consumer string, channel chan<- LogRecord[T]) Subscription {
+	return f.subscribe(func() (uint64, error) {
+		offset, _ := f.Committed(consumer)
+		return offset, nil
+	}, channel)
+}
+
+func (f *LogFeed[T]) subscribe(start func() (uint64, error), channel chan<- LogRecord[T]) Subscription {
+	return NewSubscription(func(quit <-chan struct{}) error {
+		pos, err := start()
+		if err != nil {
+			return err
+		}
+		send := func(rec LogRecord[T]) error {
+			select {
+			case channel <- rec:
+				return nil
+			case <-quit:
+				return errUnsubscribed
+			case <-f.quit:
+				return ErrLogClosed
+			}
+		}
+		for {
+			// Catch up from disk until no record was appended in the meantime, then
+			// switch to live delivery under f.mu, before the next record is written.
+			f.mu.Lock()
+			if f.closed {
+				f.mu.Unlock()
+				return ErrLogClosed
+			}
+			if pos >= f.next {
+				err = f.follow(pos, quit, send)
+				if err == errUnsubscribed {
+					return nil
+				}
+				return err
+			}
+			end := f.next
+			segs := f.segmentsFrom(pos)
+			f.mu.Unlock()
+
+			pos, err = readSegments(segs, pos, end, send)
+			if err == errUnsubscribed {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+var errUnsubscribed = errors.New("unsubscribed")
+
+// follow subscribes to live appends and forwards those at or after pos using send
+// until quit is closed. It must be called with f.mu held and releases it. Records
+// before pos, e.g. of an Append still delivering, are skipped.
+func (f *LogFeed[T]) follow(pos uint64, quit <-chan struct{}, send func(LogRecord[T]) error) error {
+	live := make(chan LogRecord[T], adapterBuffer)
+	sub := f.feed.Subscribe(live)
+	f.mu.Unlock()
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case rec := <-live:
+			if rec.Offset < pos {
+				continue
+			}
+			if err := send(rec); err != nil {
+				return err
+			}
+		case <-quit:
+			return errUnsubscribed
+		case <-f.quit:
+			return ErrLogClosed
+		}
+	}
+}
+
+// segmentsFrom returns the segments holding records at or after offset. It must be
+// called with f.mu held.
+func (f *LogFeed[T]) segmentsFrom(offset uint64) []logSegment {
+	i := sort.Search(len(f.segments), func(i int) bool { return f.segments[i].base > offset })
+	if i > 0 {
+		i--
+	}
+	return append([]logSegment(nil), f.segments[i:]...)
+}
+
+// readSegments calls fn for the records of segs with offsets in [from, to). It returns
+// the offset following the last record read.
+func readSegments[T any](segs []logSegment, from, to uint64, fn func(LogRecord[T]) error) (uint64, error) {
+	for _, seg := range segs {
+		file, err := os.Open(seg.path)
+		if errors.Is(err, os.ErrNotExist) {
+			// Deleted by retention while reading.
+			continue
+		}
+		if err != nil {
+			return from, err
+		}
+		r := bufio.NewReader(file)
+		for {
+			data, err := r.ReadBytes('\n')
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				file.Close()
+				return from, err
+			}
+			var rec LogRecord[T]
+			if err = json.Unmarshal(data, &rec); err != nil {
+				file.Close()
+				return from, fmt.Errorf("event: log %s: %w", seg.path, err)
+			}
+			if rec.Offset < from {
+				continue
+			}
+			if rec.Offset >= to {
+				file.Close()
+				return to, nil
+			}
+			if err = fn(rec); err != nil {
+				file.Close()
+				return from, err
+			}
+			from = rec.Offset + 1
+		}
+		file.Close()
+	}
+	return to, nil
+}
+
+// OffsetAt returns the offset of the first record stored at or after t, or the next
+// offset if there is none.
+func (f *LogFeed[T]) OffsetAt(t time.Time) (uint64, error) {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return 0, ErrLogClosed
+	}
+	var segs []logSegment
+	for _, seg := range f.segments {
+		if !seg.last.Before(t) {
+			segs = append(segs, seg)
+		}
+	}
+	end := f.next
+	f.mu.Unlock()
+
+	found := end
+	errFound := errors.New("found")
+	_, err := readSegments(segs, 0, end, func(rec LogRecord[T]) error {
+		if rec.Time.Before(t) {
+			return nil
+		}
+		found = rec.Offset
+		return errFound
+	})
+	if err != nil && err != errFound {
+		return 0, err
+	}
+	return found, nil
+}
+
+func (f *LogFeed[T]) loadOffsets() error {
+	data, err := os.ReadFile(filepath.Join(f.dir, offsetsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &f.offsets)
+}
+
+// Commit records that consumer has processed every record before offset, i.e. offset
+// is the next record it wants to receive. Committed offsets survive reopening the log.
+func (f *LogFeed[T]) Commit(consumer string, offset uint64) error {
+	f.offMu.Lock()
+	defer f.offMu.Unlock()
+	prev, had := f.offsets[consumer]
+	f.offsets[consumer] = offset
+	if err := f.saveOffsets(); err != nil {
+		if had {
+			f.offsets[consumer] = prev
+		} else {
+			delete(f.offsets, consumer)
+		}
+		return err
+	}
+	return nil
+}
+
+// Committed returns the offset last committed by consumer.
+func (f *LogFeed[T]) Committed(consumer string) (offset uint64, ok bool) {
+	f.offMu.Lock()
+	defer f.offMu.Unlock()
+	offset, ok = f.offsets[consumer]
+	return offset, ok
+}
+
+// saveOffsets writes the committed offsets to a temporary file and renames it over
+// the previous one. It must be called with f.offMu held.
+func (f *LogFeed[T]) saveOffsets() error {
+	data, err := json.Marshal(f.offsets)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.dir, offsetsFile+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(f.dir, offsetsFile))
+}
@@ -0,0 +1,255 @@
+package event
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// logClock is a manually advanced clock for LogFeed tests.
+type logClock struct{ now time.Time }
+
+func (c *logClock) Now() time.Time { return c.now }
+
+func openTestLog(t *testing.T, dir string, opts ...LogOption) *LogFeed[int] {
+	t.Helper()
+	f, err := OpenLogFeed[int](dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func appendAll(t *testing.T, f *LogFeed[int], values ...int) {
+	t.Helper()
+	for _, v := range values {
+		if _, err := f.Append(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func expectRecords(t *testing.T, ch <-chan LogRecord[int], from uint64, values ...int) {
+	t.Helper()
+	for i, want := range values {
+		select {
+		case rec := <-ch:
+			if rec.Offset != from+uint64(i) || rec.Value != want {
+				t.Fatalf("received offset %d value %d, want offset %d value %d", rec.Offset, rec.Value, from+uint64(i), want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for offset %d", from+uint64(i))
+		}
+	}
+}
+
+func TestLogFeedSubscribeFrom(t *testing.T) {
+	f := openTestLog(t, t.TempDir())
+	appendAll(t, f, 10, 11, 12, 13, 14)
+
+	ch := make(chan LogRecord[int])
+	sub := f.SubscribeFrom(2, ch)
+	defer sub.Unsubscribe()
+	expectRecords(t, ch, 2, 12, 13, 14)
+
+	// After catching up, the subscriber follows live appends.
+	go func() {
+		f.Append(15)
+		f.Append(16)
+	}()
+	expectRecords(t, ch, 5, 15, 16)
+}
+
+func TestLogFeedReopen(t *testing.T) {
+	dir := t.TempDir()
+	f, err := OpenLogFeed[int](dir, WithSegmentSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		appendAll(t, f, i)
+	}
+	f.Close()
+	if _, err := f.Append(1); err != ErrLogClosed {
+		t.Fatalf("Append after Close returned %v, want ErrLogClosed", err)
+	}
+
+	// Simulate a crash in the middle of writing a record.
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(segs) < 2 {
+		t.Fatalf("got %d segments, want several", len(segs))
+	}
+	last, err := os.OpenFile(segs[len(segs)-1], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last.WriteString(`{"offset":10,"ti`)
+	last.Close()
+
+	f = openTestLog(t, dir, WithSegmentSize(100))
+	if first, next := f.Offsets(); first != 0 || next != 10 {
+		t.Fatalf("Offsets() = %d, %d, want 0, 10", first, next)
+	}
+	if off, _ := f.Append(10); off != 10 {
+		t.Fatalf("Append returned offset %d, want 10", off)
+	}
+	ch := make(chan LogRecord[int], 11)
+	sub := f.SubscribeFrom(0, ch)
+	defer sub.Unsubscribe()
+	expectRecords(t, ch, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+}
+
+func TestLogFeedConsumer(t *testing.T) {
+	dir := t.TempDir()
+	f, err := OpenLogFeed[int](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendAll(t, f, 0, 1, 2, 3)
+	if err = f.Commit("indexer", 2); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	f = openTestLog(t, dir)
+	if off, ok := f.Committed("indexer"); !ok || off != 2 {
+		t.Fatalf("Committed = %d, %v, want 2, true", off, ok)
+	}
+	if _, ok := f.Committed("other"); ok {
+		t.Fatal("unknown consumer has a committed offset")
+	}
+	ch := make(chan LogRecord[int])
+	sub := f.SubscribeConsumer("indexer", ch)
+	defer sub.Unsubscribe()
+	expectRecords(t, ch, 2, 2, 3)
+}
+
+func TestLogFeedSubscribeSince(t *testing.T) {
+	clock := &logClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	f := openTestLog(t, t.TempDir(), WithLogClock(clock.Now), WithSegmentSize(120))
+	start := clock.now
+	for i := 0; i < 6; i++ {
+		appendAll(t, f, i)
+		clock.now = clock.now.Add(time.Second)
+	}
+	if off, err := f.OffsetAt(start.Add(3 * time.Second)); err != nil || off != 3 {
+		t.Fatalf("OffsetAt = %d, %v, want 3", off, err)
+	}
+	if off, _ := f.OffsetAt(start.Add(time.Hour)); off != 6 {
+		t.Fatalf("OffsetAt in the future = %d, want 6", off)
+	}
+	ch := make(chan LogRecord[int])
+	sub := f.SubscribeSince(start.Add(4*time.Second), ch)
+	defer sub.Unsubscribe()
+	expectRecords(t, ch, 4, 4, 5)
+}
+
+func TestLogFeedRetention(t *testing.T) {
+	clock := &logClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dir := t.TempDir()
+	f := openTestLog(t, dir, WithLogClock(clock.Now), WithSegmentSize(100), WithRetention(300, time.Hour))
+	for i := 0; i < 30; i++ {
+		appendAll(t, f, i)
+	}
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	var total int64
+	for _, seg := range segs {
+		info, _ := os.Stat(seg)
+		total += info.Size()
+	}
+	// The size limit may be exceeded by at most the active segment.
+	if total > 400 {
+		t.Errorf("log holds %d bytes, want at most 400", total)
+	}
+	first, next := f.Offsets()
+	if first == 0 || next != 30 {
+		t.Fatalf("Offsets() = %d, %d, want first > 0, next 30", first, next)
+	}
+
+	// A subscriber starting before the retained records skips to the oldest one.
+	ch := make(chan LogRecord[int], 30)
+	sub := f.SubscribeFrom(0, ch)
+	expectRecords(t, ch, first, int(first))
+	sub.Unsubscribe()
+
+	// Age based retention deletes everything but the active segment.
+	clock.now = clock.now.Add(2 * time.Hour)
+	if err := f.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if segs, _ = filepath.Glob(filepath.Join(dir, "*.log")); len(segs) != 1 {
+		t.Fatalf("got %d segments after compaction, want 1", len(segs))
+	}
+}
+
+func TestLogFeedClose(t *testing.T) {
+	f := openTestLog(t, t.TempDir())
+	appendAll(t, f, 1)
+	ch := make(chan LogRecord[int])
+	sub := f.SubscribeFrom(0, ch)
+	expectRecords(t, ch, 0, 1)
+
+	// Close must not wait for the subscriber, which stopped reading.
+	go f.Append(2)
+	time.Sleep(10 * time.Millisecond)
+	f.Close()
+	select {
+	case err := <-sub.Err():
+		if err != ErrLogClosed {
+			t.Fatalf("Err() delivered %v, want ErrLogClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription not ended by Close")
+	}
+}
+
+func TestLogFeedCallback(t *testing.T) {
+	f := openTestLog(t, t.TempDir())
+
+	// The subscriber uses the log while Append waits for it to receive.
+	const n = 100
+	ch := make(chan LogRecord[int])
+	sub := f.SubscribeFrom(0, ch)
+	defer sub.Unsubscribe()
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < n; i++ {
+			rec := <-ch
+			if rec.Offset != uint64(i) {
+				done <- fmt.Errorf("received offset %d, want %d", rec.Offset, i)
+				return
+			}
+			f.Offsets()
+			if _, err := f.OffsetAt(rec.Time); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	appended := make(chan error, 1)
+	go func() {
+		for i := 0; i < n; i++ {
+			if _, err := f.Append(i); err != nil {
+				appended <- err
+				return
+			}
+		}
+		appended <- nil
+	}()
+	select {
+	case err := <-appended:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Append blocked by a subscriber using the log")
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
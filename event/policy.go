@@ -0,0 +1,204 @@
+package event
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrSlowSubscriber is delivered on Subscription.Err() when a subscription using the
+// Disconnect policy could not accept a value and was dropped by the feed.
+var ErrSlowSubscriber = errors.New("event: subscriber too slow, disconnected")
+
+// DeliveryPolicy decides what a feed does when a subscribed channel cannot accept a
+// value immediately.
+type DeliveryPolicy int
+
+const (
+	// Block waits until the channel accepts the value. This is the default and
+	// stalls Send for all other subscribers while waiting.
+	Block DeliveryPolicy = iota
+	// DropNewest discards the value being sent if the channel is full.
+	DropNewest
+	// DropOldest queues values in an internal ring buffer which is drained into the
+	// channel by a helper goroutine. When the ring is full, the oldest queued value
+	// is discarded.
+	DropOldest
+	// Disconnect ends the subscription with ErrSlowSubscriber if the channel is full.
+	Disconnect
+)
+
+const defaultRingSize = 16
+
+// SubscribeOption configures a subscription when it is created.
+type SubscribeOption func(*subOptions)
+
+type subOptions struct {
+	policy      DeliveryPolicy
+	ringSize    int
+	concurrency int
+	priority    int
+	skippable   bool
+}
+
+func newSubOptions(opts []SubscribeOption) subOptions {
+	o := subOptions{policy: Block, ringSize: defaultRingSize, concurrency: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithPolicy sets the delivery policy of the subscription.
+func WithPolicy(policy DeliveryPolicy) SubscribeOption {
+	return func(o *subOptions) {
+		o.policy = policy
+	}
+}
+
+// WithRingSize sets the ring buffer size used by the DropOldest policy.
+func WithRingSize(n int) SubscribeOption {
+	return func(o *subOptions) {
+		if n > 0 {
+			o.ringSize = n
+		}
+	}
+}
+
+// WithConcurrency sets the number of workers running the handler of a subscription
+// created by SubscribeHandler. It is ignored by channel subscriptions.
+func WithConcurrency(n int) SubscribeOption {
+	return func(o *subOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithPriority sets the priority of a subscription to a Feed. Send delivers to the
+// subscriptions of higher priority first and moves on to the next lower priority only
+// once all of them accepted the value. The default priority is 0. Subscriptions with a
+// non-blocking policy are always served first. It is ignored by FeedOf.
+func WithPriority(p int) SubscribeOption {
+	return func(o *subOptions) {
+		o.priority = p
+	}
+}
+
+// SkipUnderBackpressure makes Feed.Send skip the subscription if its channel is not
+// ready and Send already had to wait for a subscription of higher priority. Skipped
+// values are not counted as sent. It is ignored by FeedOf.
+func SkipUnderBackpressure() SubscribeOption {
+	return func(o *subOptions) {
+		o.skippable = true
+	}
+}
+
+// CloseOption configures Feed.Close and FeedOf.Close.
+type CloseOption func(*closeOptions)
+
+type closeOptions struct {
+	closeChannels bool
+}
+
+func newCloseOptions(opts []CloseOption) closeOptions {
+	var o closeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// CloseChannels makes Close also close the channels of the subscriptions it ends, so
+// that receivers can range over them. Every channel is closed once even if it was
+// subscribed more than once. It must not be used if the channels are written to by
+// anything but the feed.
+func CloseChannels() CloseOption {
+	return func(o *closeOptions) {
+		o.closeChannels = true
+	}
+}
+
+// ring is a fixed size FIFO which overwrites its oldest element when full.
+type ring[T any] struct {
+	mu     sync.Mutex
+	buf    []T
+	head   int
+	size   int
+	notify chan struct{} // signals the forwarding goroutine, has a one-element buffer
+	quit   chan struct{}
+	done   chan struct{} // closed when run returns
+}
+
+func newRing[T any](n int) *ring[T] {
+	return &ring[T]{
+		buf:    make([]T, n),
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// push appends v, discarding the oldest element if the ring is full.
+func (r *ring[T]) push(v T) {
+	r.mu.Lock()
+	if r.size == len(r.buf) {
+		r.buf[r.head] = v
+		r.head = (r.head + 1) % len(r.buf)
+	} else {
+		r.buf[(r.head+r.size)%len(r.buf)] = v
+		r.size++
+	}
+	r.mu.Unlock()
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (r *ring[T]) pop() (v T, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size == 0 {
+		return v, false
+	}
+	var zero T
+	v, r.buf[r.head] = r.buf[r.head], zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.size--
+	return v, true
+}
+
+func (r *ring[T]) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.size
+}
+
+// run forwards queued elements using send until the ring is stopped. send must
+// return false if it was interrupted by r.quit.
+func (r *ring[T]) run(send func(T) bool) {
+	defer close(r.done)
+	for {
+		select {
+		case <-r.notify:
+		case <-r.quit:
+			return
+		}
+		for {
+			v, ok := r.pop()
+			if !ok {
+				break
+			}
+			if !send(v) {
+				return
+			}
+		}
+	}
+}
+
+// stop ends run and waits for it to return.
+func (r *ring[T]) stop() {
+	close(r.quit)
+	<-r.done
+}
@@ -0,0 +1,168 @@
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Checks that a subscriber which never reads does not stall Send when it uses a
+// non-blocking policy. Mirrors TestFeedSubscribeBlockedPost.
+func TestFeedSubscribeBlockedPostPolicy(t *testing.T) {
+	for _, policy := range []DeliveryPolicy{DropNewest, DropOldest, Disconnect} {
+		var (
+			feed   Feed
+			nsends = 2000
+			ch1    = make(chan int)
+			stuck  = make(chan int)
+			wg     sync.WaitGroup
+		)
+		feed.Subscribe(ch1)
+		sub2 := feed.Subscribe(stuck, WithPolicy(policy))
+
+		wg.Add(nsends)
+		for i := 0; i < nsends; i++ {
+			go func() {
+				feed.Send(99)
+				wg.Done()
+			}()
+		}
+		for i := 0; i < nsends; i++ {
+			<-ch1
+		}
+		wg.Wait()
+		sub2.Unsubscribe()
+	}
+}
+
+func TestFeedOfSubscribeBlockedPostPolicy(t *testing.T) {
+	for _, policy := range []DeliveryPolicy{DropNewest, DropOldest, Disconnect} {
+		var (
+			feed   FeedOf[int]
+			nsends = 2000
+			ch1    = make(chan int)
+			stuck  = make(chan int)
+			wg     sync.WaitGroup
+		)
+		feed.Subscribe(ch1)
+		sub2 := feed.Subscribe(stuck, WithPolicy(policy))
+
+		wg.Add(nsends)
+		for i := 0; i < nsends; i++ {
+			go func() {
+				feed.Send(99)
+				wg.Done()
+			}()
+		}
+		for i := 0; i < nsends; i++ {
+			<-ch1
+		}
+		wg.Wait()
+		sub2.Unsubscribe()
+	}
+}
+
+func TestFeedOfDropNewest(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		ch   = make(chan int, 2)
+		sub  = feed.Subscribe(ch, WithPolicy(DropNewest))
+	)
+	defer sub.Unsubscribe()
+
+	for i := 1; i <= 4; i++ {
+		want := 1
+		if i > 2 {
+			want = 0
+		}
+		if nsent := feed.Send(i); nsent != want {
+			t.Errorf("send %d delivered %d times, want %d", i, nsent, want)
+		}
+	}
+	if v1, v2 := <-ch, <-ch; v1 != 1 || v2 != 2 {
+		t.Errorf("received %d, %d, want 1, 2", v1, v2)
+	}
+}
+
+func TestFeedOfDropOldest(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		ch   = make(chan int)
+		sub  = feed.Subscribe(ch, WithPolicy(DropOldest), WithRingSize(3))
+	)
+	defer sub.Unsubscribe()
+
+	// The helper goroutine takes the first value and blocks sending it on ch,
+	// wait until that happens so the ring contents are deterministic.
+	feed.Send(0)
+	waitFor(t, func() bool { return sub.(*FeedOfSub[int]).ring.len() == 0 })
+	for i := 1; i <= 10; i++ {
+		if nsent := feed.Send(i); nsent != 1 {
+			t.Errorf("send %d delivered %d times, want 1", i, nsent)
+		}
+	}
+	for _, want := range []int{0, 8, 9, 10} {
+		if v := <-ch; v != want {
+			t.Errorf("received %d, want %d", v, want)
+		}
+	}
+}
+
+func TestFeedOfDisconnect(t *testing.T) {
+	var (
+		feed FeedOf[int]
+		ch   = make(chan int, 1)
+		sub  = feed.Subscribe(ch, WithPolicy(Disconnect))
+	)
+	if nsent := feed.Send(1); nsent != 1 {
+		t.Errorf("first send delivered %d times, want 1", nsent)
+	}
+	if nsent := feed.Send(2); nsent != 0 {
+		t.Errorf("second send delivered %d times, want 0", nsent)
+	}
+	select {
+	case err := <-sub.Err():
+		if err != ErrSlowSubscriber {
+			t.Errorf("got error %v, want %v", err, ErrSlowSubscriber)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no error after disconnect")
+	}
+	<-ch
+	if nsent := feed.Send(3); nsent != 0 {
+		t.Errorf("send after disconnect delivered %d times, want 0", nsent)
+	}
+	sub.Unsubscribe()
+	if _, ok := <-sub.Err(); ok {
+		t.Errorf("error channel not closed after unsubscribe")
+	}
+}
+
+func TestFeedDisconnect(t *testing.T) {
+	var (
+		feed Feed
+		ch   = make(chan int)
+		sub  = feed.Subscribe(ch, WithPolicy(Disconnect))
+	)
+	defer sub.Unsubscribe()
+	if nsent := feed.Send(1); nsent != 0 {
+		t.Errorf("send delivered %d times, want 0", nsent)
+	}
+	if err := <-sub.Err(); err != ErrSlowSubscriber {
+		t.Errorf("got error %v, want %v", err, ErrSlowSubscriber)
+	}
+	if len(feed.nonblocking) != 0 {
+		t.Errorf("disconnected subscription not removed")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
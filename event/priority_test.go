@@ -0,0 +1,120 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFeedPriorityOrder(t *testing.T) {
+	var (
+		feed Feed
+		low  = make(chan int)
+		mid  = make(chan int)
+		high = make(chan int)
+	)
+	// Subscribe in reverse order so that the order of subscription does not decide.
+	defer feed.Subscribe(low).Unsubscribe()
+	defer feed.Subscribe(mid, WithPriority(1)).Unsubscribe()
+	defer feed.Subscribe(high, WithPriority(2)).Unsubscribe()
+
+	for i := 0; i < 10; i++ {
+		done := make(chan int)
+		go func() { done <- feed.Send(i) }()
+		// A receiver ready on all channels gets the value on the highest priority
+		// first because Send offers the lower tiers only afterwards.
+		var got []string
+		for len(got) < 3 {
+			select {
+			case <-low:
+				got = append(got, "low")
+			case <-mid:
+				got = append(got, "mid")
+			case <-high:
+				got = append(got, "high")
+			}
+		}
+		if got[0] != "high" || got[1] != "mid" || got[2] != "low" {
+			t.Fatalf("send %d: delivery order %v, want [high mid low]", i, got)
+		}
+		if n := <-done; n != 3 {
+			t.Fatalf("send %d: delivered to %d subscribers, want 3", i, n)
+		}
+	}
+}
+
+func TestFeedPrioritySkip(t *testing.T) {
+	var (
+		feed     Feed
+		high     = make(chan int)
+		skipped  = make(chan int)
+		buffered = make(chan int, 1)
+	)
+	defer feed.Subscribe(high, WithPriority(1)).Unsubscribe()
+	defer feed.Subscribe(skipped, SkipUnderBackpressure()).Unsubscribe()
+	defer feed.Subscribe(buffered, SkipUnderBackpressure()).Unsubscribe()
+
+	// The high priority subscriber blocks Send, so the skippable subscriber which is
+	// not ready is skipped. The one with buffer space still receives.
+	done := make(chan int)
+	go func() { done <- feed.Send(1) }()
+	time.Sleep(10 * time.Millisecond)
+	expectRecv(t, high, 1)
+	if n := <-done; n != 2 {
+		t.Errorf("delivered to %d subscribers, want 2", n)
+	}
+	expectRecv(t, buffered, 1)
+
+	// Without backpressure, Send waits for skippable subscribers.
+	var feed2 Feed
+	defer feed2.Subscribe(make(chan int, 1), WithPriority(1)).Unsubscribe()
+	defer feed2.Subscribe(skipped, SkipUnderBackpressure()).Unsubscribe()
+	go func() { done <- feed2.Send(2) }()
+	time.Sleep(10 * time.Millisecond)
+	expectRecv(t, skipped, 2)
+	if n := <-done; n != 2 {
+		t.Errorf("delivered to %d subscribers, want 2", n)
+	}
+}
+
+func TestFeedPriorityUnsubscribe(t *testing.T) {
+	var (
+		feed Feed
+		high = make(chan int)
+		low  = make(chan int, 1)
+	)
+	highSub := feed.Subscribe(high, WithPriority(1))
+	defer feed.Subscribe(low).Unsubscribe()
+
+	// Removing the blocked high priority subscriber lets Send move on.
+	done := make(chan int)
+	go func() { done <- feed.Send(1) }()
+	time.Sleep(10 * time.Millisecond)
+	if len(low) != 0 {
+		t.Fatal("low priority subscriber received before high priority one")
+	}
+	highSub.Unsubscribe()
+	if n := <-done; n != 1 {
+		t.Errorf("delivered to %d subscribers, want 1", n)
+	}
+	expectRecv(t, low, 1)
+}
+
+func TestFeedPrioritySendContext(t *testing.T) {
+	var feed Feed
+	high := feed.Subscribe(make(chan int), WithPriority(1))
+	defer high.Unsubscribe()
+	low := feed.Subscribe(make(chan int, 1))
+	defer low.Unsubscribe()
+
+	// The lower tier was never offered the value, so it is missed as well.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	nsent, missed, err := feed.SendContext(ctx, 1)
+	if nsent != 0 || err != context.DeadlineExceeded {
+		t.Fatalf("SendContext returned %d, %v", nsent, err)
+	}
+	if len(missed) != 2 || missed[0] != high || missed[1] != low {
+		t.Errorf("missed %v, want the high and low priority subscriptions", missed)
+	}
+}
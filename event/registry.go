@@ -0,0 +1,77 @@
+package event
+
+import (
+	"reflect"
+	"sync"
+)
+
+// feedRegistry holds the feeds behind Bus and TypeMux. A FeedOf is created when its
+// key gets the first subscriber and torn down when the last one unsubscribes. mu also
+// protects the state of the owner.
+type feedRegistry[K comparable] struct {
+	mu    sync.Mutex
+	feeds map[K]*sharedFeed[K]
+
+	// teardown is called with mu held after a feed has been removed, letting the
+	// owner drop what it derived from the feed.
+	teardown func(sf *sharedFeed[K])
+}
+
+type sharedFeed[K comparable] struct {
+	key   K
+	feed  interface{}                 // *FeedOf[T]
+	etype reflect.Type                // T
+	send  func(value interface{}) int // sends value, which must be a T, on feed
+	nsubs int
+}
+
+// subscribeShared subscribes channel to the feed of key, creating the feed if there is
+// none and reporting whether it did. It must be called with r.mu held, and the feed
+// of key must carry T.
+func subscribeShared[K comparable, T any](r *feedRegistry[K], key K, channel chan<- T, opts []SubscribeOption) (sub Subscription, sf *sharedFeed[K], created bool) {
+	if r.feeds == nil {
+		r.feeds = make(map[K]*sharedFeed[K])
+	}
+	sf = r.feeds[key]
+	if sf == nil {
+		feed := new(FeedOf[T])
+		sf = &sharedFeed[K]{
+			key:   key,
+			feed:  feed,
+			etype: reflect.TypeFor[T](),
+			send:  func(value interface{}) int { return feed.Send(value.(T)) },
+		}
+		r.feeds[key] = sf
+		created = true
+	}
+	sf.nsubs++
+	inner := sf.feed.(*FeedOf[T]).Subscribe(channel, opts...)
+	return &sharedSub[K]{Subscription: inner, registry: r, feed: sf}, sf, created
+}
+
+// release drops a subscriber of sf, removing the feed when it was the last one.
+func (r *feedRegistry[K]) release(sf *sharedFeed[K]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sf.nsubs--
+	if sf.nsubs == 0 && r.feeds[sf.key] == sf {
+		delete(r.feeds, sf.key)
+		if r.teardown != nil {
+			r.teardown(sf)
+		}
+	}
+}
+
+type sharedSub[K comparable] struct {
+	Subscription
+	registry *feedRegistry[K]
+	feed     *sharedFeed[K]
+	once     sync.Once
+}
+
+func (sub *sharedSub[K]) Unsubscribe() {
+	sub.once.Do(func() {
+		sub.Subscription.Unsubscribe()
+		sub.registry.release(sub.feed)
+	})
+}
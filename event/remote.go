@@ -0,0 +1,175 @@
+package event
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// remoteBuffer is the buffer size of the channel subscribed to the feed for every
+// remote connection.
+const remoteBuffer = 64
+
+// Codec turns values into a byte stream and back for ServeFeed and DialFeed.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+var (
+	// JSONCodec encodes values as a stream of JSON documents.
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec encodes values as a gob stream.
+	GobCodec Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+func (jsonCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+type gobCodec struct{}
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder { return gob.NewEncoder(w) }
+func (gobCodec) NewDecoder(r io.Reader) Decoder { return gob.NewDecoder(r) }
+
+// ServeFeed accepts connections on ln and streams every value sent on feed to each of
+// them, encoded with codec. Every connection is backed by its own subscription created
+// with opts. WithPolicy(DropOldest) is recommended so that a slow network peer cannot
+// stall the feed.
+//
+// ServeFeed returns when ln is closed, after closing all connections.
+func ServeFeed[T any](feed *FeedOf[T], ln net.Listener, codec Codec, opts ...SubscribeOption) error {
+	var (
+		wg   sync.WaitGroup
+		quit = make(chan struct{})
+	)
+	defer func() {
+		close(quit)
+		wg.Wait()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConn(feed, conn, codec, opts, quit)
+		}()
+	}
+}
+
+func serveConn[T any](feed *FeedOf[T], conn net.Conn, codec Codec, opts []SubscribeOption, quit <-chan struct{}) {
+	defer conn.Close()
+	ch := make(chan T, remoteBuffer)
+	sub := feed.Subscribe(ch, opts...)
+	defer sub.Unsubscribe()
+
+	// Closing the connection on quit interrupts an Encode blocked on a peer which
+	// stopped reading.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-quit:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// Peers never write, reading only detects that they hung up.
+	hangup := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(hangup)
+	}()
+
+	enc := codec.NewEncoder(conn)
+	for {
+		select {
+		case v := <-ch:
+			if err := enc.Encode(v); err != nil {
+				return
+			}
+		case <-sub.Err():
+			return
+		case <-hangup:
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// DialFeed connects to a feed served by ServeFeed and delivers the values received on
+// channel. The subscription fails with an error when the connection is lost. Values
+// must be decoded with the codec the server uses.
+func DialFeed[T any](ctx context.Context, network, addr string, codec Codec, channel chan<- T) (Subscription, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	dec := codec.NewDecoder(conn)
+	return NewSubscription(func(quit <-chan struct{}) error {
+		defer conn.Close()
+		// Closing the connection interrupts a blocked Decode on Unsubscribe.
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-quit:
+				conn.Close()
+			case <-stop:
+			}
+		}()
+
+		for {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				select {
+				case <-quit:
+					return nil
+				default:
+					return fmt.Errorf("event: remote feed %s disconnected: %w", addr, err)
+				}
+			}
+			select {
+			case channel <- v:
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// DialFeedReconnect is like DialFeed, but reconnects whenever the connection fails,
+// with backoff as in Resubscribe. Every disconnect is passed to onDisconnect, which
+// may be nil. Values sent on the feed while disconnected are lost.
+func DialFeedReconnect[T any](network, addr string, codec Codec, channel chan<- T, backoffMax time.Duration, onDisconnect func(error)) Subscription {
+	return ResubscribeErr(backoffMax, func(ctx context.Context, lastErr error) (Subscription, error) {
+		if lastErr != nil && onDisconnect != nil {
+			onDisconnect(lastErr)
+		}
+		return DialFeed(ctx, network, addr, codec, channel)
+	})
+}
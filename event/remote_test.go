@@ -0,0 +1,201 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type remoteEvent struct {
+	Name  string
+	Value int
+}
+
+// serveTest serves feed on ln until the test ends.
+func serveTest(t *testing.T, feed *FeedOf[remoteEvent], ln net.Listener, codec Codec) (stop func()) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- ServeFeed(feed, ln, codec) }()
+	stop = sync.OnceFunc(func() {
+		ln.Close()
+		if err := <-done; err != nil {
+			t.Errorf("ServeFeed error: %v", err)
+		}
+	})
+	t.Cleanup(stop)
+	return stop
+}
+
+func TestRemoteFeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		codec   Codec
+	}{
+		{"tcp-json", "tcp", JSONCodec},
+		{"unix-gob", "unix", GobCodec},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := "127.0.0.1:0"
+			if test.network == "unix" {
+				addr = filepath.Join(t.TempDir(), "feed.sock")
+			}
+			ln, err := net.Listen(test.network, addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Replay makes the values reach the remote subscriber regardless of
+			// when the server subscribes on its behalf.
+			var feed FeedOf[remoteEvent]
+			feed.SetReplay(3)
+			serveTest(t, &feed, ln, test.codec)
+
+			ch := make(chan remoteEvent)
+			sub, err := DialFeed(context.Background(), test.network, ln.Addr().String(), test.codec, ch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 3; i++ {
+				feed.Send(remoteEvent{Name: "ev", Value: i})
+			}
+			for i := 0; i < 3; i++ {
+				expectRecv(t, ch, remoteEvent{Name: "ev", Value: i})
+			}
+			sub.Unsubscribe()
+			if err, ok := <-sub.Err(); ok {
+				t.Errorf("Err() delivered %v after Unsubscribe", err)
+			}
+		})
+	}
+}
+
+func TestRemoteFeedDisconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed FeedOf[remoteEvent]
+	feed.SetReplay(1)
+	stop := serveTest(t, &feed, ln, JSONCodec)
+
+	ch := make(chan remoteEvent)
+	sub, err := DialFeed(context.Background(), "tcp", ln.Addr().String(), JSONCodec, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	feed.Send(remoteEvent{Value: 1})
+	expectRecv(t, ch, remoteEvent{Value: 1})
+
+	// Shutting down the server closes the connection.
+	stop()
+	select {
+	case err := <-sub.Err():
+		if err == nil {
+			t.Fatal("Err() delivered nil error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("disconnect not reported")
+	}
+}
+
+func TestRemoteFeedStalledPeer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed FeedOf[remoteEvent]
+	done := make(chan error, 1)
+	go func() { done <- ServeFeed(&feed, ln, JSONCodec, WithPolicy(DropOldest)) }()
+
+	// The peer never reads, so the server blocks writing the large values.
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	waitFor(t, func() bool { return feed.Stats().Subscribers() == 1 })
+	big := strings.Repeat("x", 1<<20)
+	for i := 0; i < 16; i++ {
+		feed.Send(remoteEvent{Name: big, Value: i})
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	ln.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ServeFeed error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeFeed did not return after the listener was closed")
+	}
+}
+
+func TestRemoteFeedUnsubscribeServerSide(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed FeedOf[remoteEvent]
+	feed.SetReplay(1)
+	serveTest(t, &feed, ln, GobCodec)
+
+	ch := make(chan remoteEvent)
+	sub, err := DialFeed(context.Background(), "tcp", ln.Addr().String(), GobCodec, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed.Send(remoteEvent{Value: 1})
+	expectRecv(t, ch, remoteEvent{Value: 1})
+
+	// Once the client hangs up, the server drops its subscription to the feed.
+	sub.Unsubscribe()
+	waitFor(t, func() bool { return feed.Send(remoteEvent{}) == 0 })
+}
+
+func TestRemoteFeedReconnect(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "feed.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed1 FeedOf[remoteEvent]
+	feed1.SetReplay(1)
+	stop := serveTest(t, &feed1, ln, JSONCodec)
+
+	var disconnects atomic.Int32
+	ch := make(chan remoteEvent)
+	sub := DialFeedReconnect("unix", sock, JSONCodec, ch, 10*time.Millisecond, func(err error) {
+		if err == nil || errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected disconnect error %v", err)
+		}
+		disconnects.Add(1)
+	})
+	defer sub.Unsubscribe()
+	feed1.Send(remoteEvent{Name: "first"})
+	expectRecv(t, ch, remoteEvent{Name: "first"})
+
+	// Restart the server on the same address.
+	stop()
+	ln, err = net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed2 FeedOf[remoteEvent]
+	feed2.SetReplay(1)
+	serveTest(t, &feed2, ln, JSONCodec)
+	feed2.Send(remoteEvent{Name: "second"})
+	expectRecv(t, ch, remoteEvent{Name: "second"})
+
+	if n := disconnects.Load(); n != 1 {
+		t.Errorf("got %d disconnects, want 1", n)
+	}
+}
@@ -0,0 +1,105 @@
+package event
+
+import "reflect"
+
+// SetReplay makes the feed remember the last n values sent and deliver them to every
+// new subscriber before any live value. SetReplay(1) gives last-value semantics: a
+// subscriber immediately learns the current state. n <= 0 disables replay and drops
+// the remembered values.
+//
+// Values are replayed by a helper goroutine which forwards live values after the
+// backlog, keeping them in order. While it runs, values sent to the subscriber are
+// buffered in an internal channel with the capacity of the subscribed one. Once the
+// backlog and the buffered values are delivered, the feed sends to the subscribed
+// channel directly again. A DropOldest subscription instead queues the backlog in its
+// ring buffer, dropping the oldest values if the ring is too small.
+func (f *FeedOf[T]) SetReplay(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replay = max(n, 0)
+	if len(f.history) > f.replay {
+		f.history = append([]T(nil), f.history[len(f.history)-f.replay:]...)
+	}
+}
+
+// record remembers value for replay. It must be called with f.mu held.
+func (f *FeedOf[T]) record(value T) {
+	if f.replay == 0 {
+		return
+	}
+	if len(f.history) == f.replay {
+		copy(f.history, f.history[1:])
+		f.history[len(f.history)-1] = value
+	} else {
+		f.history = append(f.history, value)
+	}
+}
+
+// backlog returns a copy of the remembered values accepted by filter. It must be
+// called with f.mu held.
+func (f *FeedOf[T]) backlog(filter func(T) bool) []T {
+	var backlog []T
+	for _, v := range f.history {
+		if filter == nil || filter(v) {
+			backlog = append(backlog, v)
+		}
+	}
+	return backlog
+}
+
+type replayer struct {
+	quit chan struct{}
+	done chan struct{}
+}
+
+func (r *replayer) stop() {
+	close(r.quit)
+	<-r.done
+}
+
+// startReplay redirects the feed's deliveries for sub to an internal channel and
+// starts forwarding the backlog followed by the live values to the subscriber. It must
+// be called with f.mu held.
+func (sub *FeedOfSub[T]) startReplay(backlog []T) {
+	out := sub.channel
+	in := make(chan T, cap(out))
+	sub.channel = in
+	sub.replay = &replayer{quit: make(chan struct{}), done: make(chan struct{})}
+	go func(quit <-chan struct{}) {
+		defer close(sub.replay.done)
+		for _, v := range backlog {
+			select {
+			case out <- v:
+			case <-quit:
+				return
+			}
+		}
+		// Forward the values sent meanwhile. Once the internal channel is empty while
+		// no Send is in progress, point the feed back to the subscribed channel.
+		f := sub.feed
+		for {
+			select {
+			case v := <-in:
+				select {
+				case out <- v:
+				case <-quit:
+					return
+				}
+			case <-f.sendLock:
+				f.mu.Lock()
+				drained := len(in) == 0
+				if drained {
+					sub.channel = out
+					sub.chanval = reflect.ValueOf(out)
+				}
+				f.mu.Unlock()
+				f.sendLock <- struct{}{}
+				if drained {
+					return
+				}
+			case <-quit:
+				return
+			}
+		}
+	}(sub.replay.quit)
+}
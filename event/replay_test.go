@@ -0,0 +1,157 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFeedOfReplayLastValue(t *testing.T) {
+	var feed FeedOf[int]
+	feed.SetReplay(1)
+	feed.Send(1)
+	feed.Send(2)
+
+	ch := make(chan int)
+	sub := feed.Subscribe(ch)
+	defer sub.Unsubscribe()
+	expectRecv(t, ch, 2)
+
+	go feed.Send(3)
+	expectRecv(t, ch, 3)
+}
+
+func TestFeedOfReplayLastN(t *testing.T) {
+	var feed FeedOf[int]
+	feed.SetReplay(3)
+	for i := 1; i <= 5; i++ {
+		feed.Send(i)
+	}
+
+	ch := make(chan int, 10)
+	sub := feed.Subscribe(ch)
+	defer sub.Unsubscribe()
+	even := make(chan int, 10)
+	esub := feed.SubscribeFunc(func(v int) bool { return v%2 == 0 }, even)
+	defer esub.Unsubscribe()
+
+	feed.Send(6)
+	for _, want := range []int{3, 4, 5, 6} {
+		expectRecv(t, ch, want)
+	}
+	expectRecv(t, even, 4)
+	expectRecv(t, even, 6)
+
+	feed.SetReplay(0)
+	late := make(chan int, 10)
+	lsub := feed.Subscribe(late)
+	defer lsub.Unsubscribe()
+	if len(late) != 0 {
+		t.Errorf("values replayed after SetReplay(0)")
+	}
+}
+
+// Checks that subscribers joining while values are being sent see a gapless,
+// duplicate-free sequence.
+func TestFeedOfReplayNoGaps(t *testing.T) {
+	const (
+		nsends = 2000
+		nsubs  = 20
+	)
+	var (
+		feed FeedOf[int]
+		wg   sync.WaitGroup
+	)
+	feed.SetReplay(5)
+	feed.Send(-1)
+
+	started := make(chan struct{})
+	wg.Add(nsubs)
+	for i := 0; i < nsubs; i++ {
+		go func() {
+			defer wg.Done()
+			<-started
+			ch := make(chan int, 4)
+			sub := feed.Subscribe(ch)
+			defer sub.Unsubscribe()
+			prev := <-ch
+			for prev != nsends-1 {
+				v := <-ch
+				if v != prev+1 {
+					t.Errorf("received %d after %d", v, prev)
+					return
+				}
+				prev = v
+			}
+		}()
+	}
+	close(started)
+	for i := 0; i < nsends; i++ {
+		feed.Send(i)
+	}
+	wg.Wait()
+}
+
+func TestFeedOfReplayNegative(t *testing.T) {
+	var feed FeedOf[int]
+	feed.SetReplay(2)
+	feed.Send(1)
+	feed.SetReplay(-1)
+	for i := 0; i < 10; i++ {
+		feed.Send(i)
+	}
+	ch := make(chan int, 10)
+	sub := feed.Subscribe(ch)
+	defer sub.Unsubscribe()
+	if len(ch) != 0 || len(feed.history) != 0 {
+		t.Errorf("values remembered after SetReplay(-1)")
+	}
+}
+
+// Checks that the subscribed channel receives directly once the backlog is delivered,
+// so the replay buffer does not add to the subscriber's buffer space.
+func TestFeedOfReplayDrained(t *testing.T) {
+	var feed FeedOf[int]
+	feed.SetReplay(2)
+	feed.Send(1)
+	feed.Send(2)
+
+	ch := make(chan int, 1)
+	sub := feed.Subscribe(ch).(*FeedOfSub[int])
+	defer sub.Unsubscribe()
+	expectRecv(t, ch, 1)
+	expectRecv(t, ch, 2)
+	waitFor(t, func() bool {
+		feed.mu.Lock()
+		defer feed.mu.Unlock()
+		return sub.channel == sub.out
+	})
+
+	// The channel holds one value, then Send blocks.
+	feed.Send(3)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, missed, _ := feed.SendContext(ctx, 4); len(missed) != 1 {
+		t.Errorf("Send did not block on the full subscriber channel")
+	}
+	if s := feed.Stats().Subscriptions[0]; s.QueueLen != 1 || s.QueueCap != 1 {
+		t.Errorf("queue %d/%d, want 1/1", s.QueueLen, s.QueueCap)
+	}
+	expectRecv(t, ch, 3)
+}
+
+func TestFeedOfReplayDropOldest(t *testing.T) {
+	var feed FeedOf[int]
+	feed.SetReplay(3)
+	for i := 1; i <= 3; i++ {
+		feed.Send(i)
+	}
+	ch := make(chan int)
+	sub := feed.Subscribe(ch, WithPolicy(DropOldest), WithRingSize(8))
+	defer sub.Unsubscribe()
+	feed.Send(4)
+	for i := 1; i <= 4; i++ {
+		expectRecv(t, ch, i)
+	}
+}
@@ -0,0 +1,139 @@
+package event
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoQuorum is returned by Requester.Request when fewer replies than the quorum
+// arrived before the deadline.
+var ErrNoQuorum = errors.New("event: request quorum not reached")
+
+// Request is delivered to the subscribers of a Requester. Every subscriber receiving
+// it is expected to call Reply once.
+type Request[Q, R any] struct {
+	Value Q
+
+	replies chan R
+	done    chan struct{} // closed when the requester stops collecting replies
+}
+
+// Reply sends resp back to the requester. It returns false if the requester no longer
+// waits for replies, because the deadline passed or the quorum was reached.
+func (r *Request[Q, R]) Reply(resp R) bool {
+	select {
+	case r.replies <- resp:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// Done returns a channel which is closed once the requester stops collecting replies.
+func (r *Request[Q, R]) Done() <-chan struct{} {
+	return r.done
+}
+
+// RequestOption configures Requester.Request.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	quorum int
+}
+
+// WithQuorum makes Request return as soon as n replies arrived. It fails with
+// ErrNoQuorum if fewer than n subscribers reply before the deadline.
+func WithQuorum(n int) RequestOption {
+	return func(o *requestOptions) {
+		if n > 0 {
+			o.quorum = n
+		}
+	}
+}
+
+// Requester sends requests to all subscribers and collects their replies, e.g. to let
+// modules vote on a shutdown. The zero value is ready to use.
+type Requester[Q, R any] struct {
+	feed FeedOf[*Request[Q, R]]
+}
+
+// Subscribe adds a channel receiving the requests, see FeedOf.Subscribe.
+func (r *Requester[Q, R]) Subscribe(channel chan<- *Request[Q, R], opts ...SubscribeOption) Subscription {
+	return r.feed.Subscribe(channel, opts...)
+}
+
+// Request delivers value to all subscribers and waits for their replies, in order of
+// arrival. Without WithQuorum it waits for a reply from every subscriber which
+// received the request. Replies are collected while the request is being delivered,
+// so a subscriber which is slow to receive it does not hold up the quorum.
+//
+// If ctx is done first, Request returns the replies collected so far along with
+// ctx.Err(), or ErrNoQuorum if a quorum was requested. It also fails with ErrNoQuorum
+// once fewer subscribers than the quorum received the request.
+func (r *Requester[Q, R]) Request(ctx context.Context, value Q, opts ...RequestOption) ([]R, error) {
+	var o requestOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	req := &Request[Q, R]{Value: value, replies: make(chan R), done: make(chan struct{})}
+	defer close(req.done)
+
+	// Delivery is abandoned when Request returns early.
+	sendCtx, cancel := context.WithCancel(ctx)
+	var (
+		nsent     int
+		sendErr   error
+		delivered = make(chan struct{})
+	)
+	go func() {
+		defer close(delivered)
+		nsent, _, sendErr = r.feed.SendContext(sendCtx, req)
+	}()
+	defer func() {
+		cancel()
+		<-delivered
+	}()
+
+	// want is unknown until delivery ends, unless a quorum was requested.
+	want := o.quorum
+	if want == 0 {
+		want = -1
+	}
+	replies := make([]R, 0, max(want, 0))
+	sending := delivered
+	for want < 0 || len(replies) < want {
+		select {
+		case resp := <-req.replies:
+			replies = append(replies, resp)
+		case <-sending:
+			sending = nil
+			switch {
+			case sendErr == ErrFeedClosed:
+				return nil, sendErr
+			case sendErr != nil:
+				return replies, expiredError(ctx, o)
+			case o.quorum > nsent:
+				// Not enough subscribers received the request.
+				return nil, ErrNoQuorum
+			case o.quorum == 0:
+				want = nsent
+			}
+		case <-ctx.Done():
+			return replies, expiredError(ctx, o)
+		}
+	}
+	return replies, nil
+}
+
+// expiredError is the error of a request whose context is done.
+func expiredError(ctx context.Context, o requestOptions) error {
+	if o.quorum > 0 {
+		return ErrNoQuorum
+	}
+	return ctx.Err()
+}
+
+// Close closes the underlying feed, see FeedOf.Close.
+func (r *Requester[Q, R]) Close(opts ...CloseOption) {
+	r.feed.Close(opts...)
+}
@@ -0,0 +1,109 @@
+package event
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+// voter replies to every request with its verdict until unsubscribed.
+func voter(r *Requester[string, bool], verdict bool, delay time.Duration) Subscription {
+	ch := make(chan *Request[string, bool])
+	sub := r.Subscribe(ch)
+	go func() {
+		for {
+			select {
+			case req := <-ch:
+				time.Sleep(delay)
+				req.Reply(verdict)
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub
+}
+
+func TestRequesterAll(t *testing.T) {
+	var r Requester[string, bool]
+	defer voter(&r, true, 0).Unsubscribe()
+	defer voter(&r, false, 0).Unsubscribe()
+	defer voter(&r, true, 10*time.Millisecond).Unsubscribe()
+
+	replies, err := r.Request(context.Background(), "shutdown?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(replies, func(i, j int) bool { return !replies[i] && replies[j] })
+	if len(replies) != 3 || replies[0] || !replies[1] || !replies[2] {
+		t.Errorf("replies %v, want [false true true]", replies)
+	}
+}
+
+func TestRequesterQuorum(t *testing.T) {
+	var r Requester[string, bool]
+	defer voter(&r, true, 0).Unsubscribe()
+	defer voter(&r, true, 0).Unsubscribe()
+	// A subscriber which never replies does not hold up the quorum.
+	defer r.Subscribe(make(chan *Request[string, bool], 10)).Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	replies, err := r.Request(ctx, "shutdown?", WithQuorum(2))
+	if err != nil || len(replies) != 2 {
+		t.Fatalf("Request = %v, %v, want two replies", replies, err)
+	}
+
+	if _, err := r.Request(ctx, "shutdown?", WithQuorum(4)); err != ErrNoQuorum {
+		t.Errorf("Request with quorum above the subscriber count returned %v, want ErrNoQuorum", err)
+	}
+}
+
+func TestRequesterQuorumStraggler(t *testing.T) {
+	var r Requester[string, bool]
+	defer voter(&r, true, 0).Unsubscribe()
+	defer voter(&r, true, 0).Unsubscribe()
+	// The request cannot be delivered to this subscriber, which does not hold up the
+	// replies of the others.
+	defer r.Subscribe(make(chan *Request[string, bool])).Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	replies, err := r.Request(ctx, "shutdown?", WithQuorum(2))
+	if err != nil || len(replies) != 2 {
+		t.Fatalf("Request = %v, %v, want two replies", replies, err)
+	}
+}
+
+func TestRequesterDeadline(t *testing.T) {
+	var r Requester[string, bool]
+	defer voter(&r, true, 0).Unsubscribe()
+
+	// The subscriber receives the request but never replies.
+	ch := make(chan *Request[string, bool], 1)
+	sub := r.Subscribe(ch)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	replies, err := r.Request(ctx, "shutdown?")
+	if err != context.DeadlineExceeded || len(replies) != 1 {
+		t.Fatalf("Request = %v, %v, want one reply and DeadlineExceeded", replies, err)
+	}
+	req := <-ch
+	select {
+	case <-req.Done():
+	default:
+		t.Fatal("request not done after Request returned")
+	}
+	if req.Reply(false) {
+		t.Error("late Reply reported success")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.Request(ctx, "shutdown?", WithQuorum(2)); err != ErrNoQuorum {
+		t.Errorf("Request with quorum returned %v, want ErrNoQuorum", err)
+	}
+}
@@ -0,0 +1,198 @@
+package event
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ShardedFeed is a typed feed like FeedOf, built for large numbers of subscribers.
+// Subscriptions are spread round-robin across shards and Send delivers to all shards
+// in parallel, each with its own fast path and select set, so a slow subscriber only
+// delays the other subscribers of its shard within a Send.
+//
+// Unsubscribe takes constant time and never waits for a Send in progress: the
+// subscription is dropped from its shard by the next Send. Unlike with Feed and
+// FeedOf, a Send in progress may therefore still deliver its value on the channel
+// after Unsubscribe has returned. Sends starting after Unsubscribe returned never do.
+//
+// Only the Block delivery policy is supported. Use NewShardedFeed to create one.
+type ShardedFeed[T any] struct {
+	shards   []*feedShard[T]
+	next     atomic.Uint64 // shard of the next subscription
+	count    atomic.Int64  // number of subscriptions
+	sendLock sync.Mutex    // serializes Send, like Feed.sendLock
+}
+
+type feedShard[T any] struct {
+	// mu protects inbox and removed, which hold the changes made since the last Send.
+	mu      sync.Mutex
+	inbox   map[*shardSub[T]]struct{}
+	removed map[*shardSub[T]]struct{}
+
+	// subs holds the subscriptions which joined the shard in an earlier Send, with
+	// their positions in index. Both are owned by the sender.
+	subs  []*shardSub[T]
+	index map[*shardSub[T]]int
+}
+
+type shardSub[T any] struct {
+	feed    *ShardedFeed[T]
+	shard   *feedShard[T]
+	channel chan<- T
+	joined  bool // moved from inbox to subs, protected by shard.mu
+	quit    chan struct{}
+	once    sync.Once
+	err     chan error
+}
+
+// NewShardedFeed creates a feed with the given number of shards. If shards is not
+// positive, it defaults to GOMAXPROCS.
+func NewShardedFeed[T any](shards int) *ShardedFeed[T] {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	f := &ShardedFeed[T]{shards: make([]*feedShard[T], shards)}
+	for i := range f.shards {
+		f.shards[i] = &feedShard[T]{
+			inbox:   make(map[*shardSub[T]]struct{}),
+			removed: make(map[*shardSub[T]]struct{}),
+			index:   make(map[*shardSub[T]]int),
+		}
+	}
+	return f
+}
+
+// Subscribe adds a channel to the feed. Future sends will be delivered on the channel
+// until the subscription is canceled. The value of a Send in progress during
+// Unsubscribe may still arrive on the channel, so receivers must not rely on the
+// channel being quiet once Unsubscribe returns.
+func (f *ShardedFeed[T]) Subscribe(channel chan<- T) Subscription {
+	shard := f.shards[(f.next.Add(1)-1)%uint64(len(f.shards))]
+	sub := &shardSub[T]{
+		feed:    f,
+		shard:   shard,
+		channel: channel,
+		quit:    make(chan struct{}),
+		err:     make(chan error),
+	}
+	shard.mu.Lock()
+	shard.inbox[sub] = struct{}{}
+	shard.mu.Unlock()
+	f.count.Add(1)
+	return sub
+}
+
+func (sub *shardSub[T]) Unsubscribe() {
+	sub.once.Do(func() {
+		// Closing quit interrupts a Send blocked on the channel.
+		close(sub.quit)
+		shard := sub.shard
+		shard.mu.Lock()
+		if sub.joined {
+			shard.removed[sub] = struct{}{}
+		} else {
+			delete(shard.inbox, sub)
+		}
+		shard.mu.Unlock()
+		sub.feed.count.Add(-1)
+		close(sub.err)
+	})
+}
+
+func (sub *shardSub[T]) Err() <-chan error {
+	return sub.err
+}
+
+// Len returns the number of subscriptions.
+func (f *ShardedFeed[T]) Len() int {
+	return int(f.count.Load())
+}
+
+// Send delivers to all subscribed channels simultaneously. It returns the number of
+// subscribers that the value was sent to.
+func (f *ShardedFeed[T]) Send(value T) (nsent int) {
+	f.sendLock.Lock()
+	defer f.sendLock.Unlock()
+
+	var (
+		wg    sync.WaitGroup
+		total atomic.Int64
+	)
+	// The calling goroutine serves the first shard.
+	wg.Add(len(f.shards) - 1)
+	for _, shard := range f.shards[1:] {
+		go func() {
+			defer wg.Done()
+			total.Add(int64(shard.send(value)))
+		}()
+	}
+	nsent = f.shards[0].send(value)
+	wg.Wait()
+	return nsent + int(total.Load())
+}
+
+// update applies the subscriptions and removals made since the last Send.
+func (s *feedShard[T]) update() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.removed {
+		// Swap the last subscription into the place of the removed one.
+		i, last := s.index[sub], len(s.subs)-1
+		s.subs[i] = s.subs[last]
+		s.index[s.subs[i]] = i
+		s.subs[last] = nil
+		s.subs = s.subs[:last]
+		delete(s.index, sub)
+		delete(s.removed, sub)
+	}
+	for sub := range s.inbox {
+		sub.joined = true
+		s.index[sub] = len(s.subs)
+		s.subs = append(s.subs, sub)
+		delete(s.inbox, sub)
+	}
+}
+
+// send delivers value to the subscriptions of the shard.
+func (s *feedShard[T]) send(value T) (nsent int) {
+	s.update()
+
+	// Fast path: try sending without blocking, collecting the subscribers which are
+	// not ready.
+	var blocked []*shardSub[T]
+	for _, sub := range s.subs {
+		select {
+		case sub.channel <- value:
+			nsent++
+		default:
+			blocked = append(blocked, sub)
+		}
+	}
+	if len(blocked) == 0 {
+		return nsent
+	}
+
+	// Select on all blocked receivers at once, like Feed, so that the order in which
+	// subscribers read does not matter. Case 2i sends to blocked[i] and case 2i+1
+	// waits for it to be unsubscribed.
+	rvalue := reflect.ValueOf(&value).Elem()
+	cases := make(caseList, 0, 2*len(blocked))
+	for _, sub := range blocked {
+		cases = append(cases,
+			reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(sub.channel), Send: rvalue},
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.quit)},
+		)
+	}
+	for len(cases) > 0 {
+		chosen, _, _ := reflect.Select(cases)
+		if chosen%2 == 0 {
+			nsent++
+		}
+		// Drop both cases of the subscriber.
+		i := chosen &^ 1
+		cases = append(cases[:i], cases[i+2:]...)
+	}
+	return nsent
+}
@@ -0,0 +1,212 @@
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedFeed(t *testing.T) {
+	var (
+		feed  = NewShardedFeed[int](4)
+		chans = make([]chan int, 10)
+		subs  = make([]Subscription, len(chans))
+	)
+	for i := range chans {
+		chans[i] = make(chan int, 1)
+		subs[i] = feed.Subscribe(chans[i])
+	}
+	if n := feed.Send(1); n != len(chans) {
+		t.Fatalf("Send delivered to %d subscribers, want %d", n, len(chans))
+	}
+	for _, ch := range chans {
+		expectRecv(t, ch, 1)
+	}
+
+	// Unsubscribe from the middle, the next Send drops the subscriptions.
+	for _, i := range []int{0, 5, 9} {
+		subs[i].Unsubscribe()
+		subs[i].Unsubscribe()
+	}
+	if n := feed.Len(); n != 7 {
+		t.Errorf("Len = %d after Unsubscribe, want 7", n)
+	}
+	if n := feed.Send(2); n != 7 {
+		t.Fatalf("Send delivered to %d subscribers, want 7", n)
+	}
+	for i, ch := range chans {
+		if i == 0 || i == 5 || i == 9 {
+			if len(ch) != 0 {
+				t.Errorf("unsubscribed channel %d received a value", i)
+			}
+			continue
+		}
+		expectRecv(t, ch, 2)
+	}
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	if n := feed.Send(3); n != 0 {
+		t.Errorf("Send delivered to %d subscribers after Unsubscribe, want 0", n)
+	}
+}
+
+func TestShardedFeedBlocked(t *testing.T) {
+	var (
+		feed    = NewShardedFeed[int](2)
+		ch1     = make(chan int)
+		ch2     = make(chan int)
+		blocked = make(chan int)
+	)
+	feed.Subscribe(ch1)
+	feed.Subscribe(ch2)
+	sub := feed.Subscribe(blocked)
+
+	// The reader takes the values in a fixed order, which must not deadlock Send.
+	done := make(chan int)
+	go func() { done <- feed.Send(1) }()
+	expectRecv(t, ch2, 1)
+	expectRecv(t, ch1, 1)
+
+	// Unsubscribing the remaining blocked subscriber releases Send.
+	time.Sleep(10 * time.Millisecond)
+	sub.Unsubscribe()
+	select {
+	case n := <-done:
+		if n != 2 {
+			t.Errorf("Send delivered to %d subscribers, want 2", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Send not released by Unsubscribe")
+	}
+}
+
+// Checks what Unsubscribe guarantees: it does not wait for a Send in progress, which
+// may or may not deliver its value, and later sends skip the subscription.
+func TestShardedFeedUnsubscribeDuringSend(t *testing.T) {
+	var (
+		feed = NewShardedFeed[int](1)
+		ch   = make(chan int)
+		sub  = feed.Subscribe(ch)
+	)
+	done := make(chan int)
+	go func() { done <- feed.Send(1) }()
+	time.Sleep(10 * time.Millisecond)
+
+	unsubscribed := make(chan struct{})
+	go func() {
+		sub.Unsubscribe()
+		close(unsubscribed)
+	}()
+	select {
+	case <-unsubscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Unsubscribe waited for the Send in progress")
+	}
+	// The Send in progress is free to pick either the channel or the unsubscription
+	// once both are ready, so a receiver may still get the value.
+	select {
+	case v := <-ch:
+		if v != 1 {
+			t.Fatalf("received %d, want 1", v)
+		}
+		if n := <-done; n != 1 {
+			t.Errorf("Send delivered to %d subscribers, want 1", n)
+		}
+	case n := <-done:
+		if n != 0 {
+			t.Errorf("Send delivered to %d subscribers, want 0", n)
+		}
+	}
+
+	// Sends starting after Unsubscribe returned skip the subscription.
+	go func() { done <- feed.Send(2) }()
+	select {
+	case v := <-ch:
+		t.Fatalf("received %d after Unsubscribe", v)
+	case n := <-done:
+		if n != 0 {
+			t.Errorf("Send delivered to %d subscribers after Unsubscribe, want 0", n)
+		}
+	}
+}
+
+func TestShardedFeedConcurrent(t *testing.T) {
+	var (
+		feed = NewShardedFeed[int](4)
+		wg   sync.WaitGroup
+	)
+	const nsubs, nsends = 50, 20
+	wg.Add(nsubs)
+	for i := 0; i < nsubs; i++ {
+		ch := make(chan int)
+		sub := feed.Subscribe(ch)
+		go func() {
+			defer wg.Done()
+			// Leave after a few values, while sends are in progress.
+			for j := 0; j < i%nsends; j++ {
+				<-ch
+			}
+			sub.Unsubscribe()
+		}()
+	}
+	for i := 0; i < nsends; i++ {
+		feed.Send(i)
+	}
+	wg.Wait()
+	if n := feed.Len(); n != 0 {
+		t.Errorf("Len = %d, want 0", n)
+	}
+}
+
+func BenchmarkFeedSend10000(b *testing.B) {
+	var feed Feed
+	benchmarkSend(b, 10000, func(ch chan int) { feed.Subscribe(ch) }, func(v int) int { return feed.Send(v) })
+}
+
+func BenchmarkShardedFeedSend1000(b *testing.B) {
+	feed := NewShardedFeed[int](0)
+	benchmarkSend(b, 1000, func(ch chan int) { feed.Subscribe(ch) }, feed.Send)
+}
+
+func BenchmarkShardedFeedSend10000(b *testing.B) {
+	feed := NewShardedFeed[int](0)
+	benchmarkSend(b, 10000, func(ch chan int) { feed.Subscribe(ch) }, feed.Send)
+}
+
+func benchmarkUnsubscribe(b *testing.B, subscribe func(chan int) Subscription, send func(int) int) {
+	const nsubs = 10000
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		subs := make([]Subscription, nsubs)
+		for j := range subs {
+			subs[j] = subscribe(make(chan int, 1))
+		}
+		send(0) // move the subscriptions out of the inbox
+		b.StartTimer()
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+		send(1) // ShardedFeed drops the subscriptions here
+	}
+}
+
+func BenchmarkFeedUnsubscribe10000(b *testing.B) {
+	var feed Feed
+	benchmarkUnsubscribe(b, func(ch chan int) Subscription { return feed.Subscribe(ch) }, func(v int) int { return feed.Send(v) })
+}
+
+func BenchmarkShardedFeedUnsubscribe10000(b *testing.B) {
+	feed := NewShardedFeed[int](0)
+	benchmarkUnsubscribe(b, func(ch chan int) Subscription { return feed.Subscribe(ch) }, feed.Send)
+}
+
+func BenchmarkFeedSendFastPath10000(b *testing.B) {
+	var feed Feed
+	benchmarkSendFastPath(b, 10000, func(ch chan int) { feed.Subscribe(ch) }, func(v int) int { return feed.Send(v) })
+}
+
+func BenchmarkShardedFeedSendFastPath10000(b *testing.B) {
+	feed := NewShardedFeed[int](0)
+	benchmarkSendFastPath(b, 10000, func(ch chan int) { feed.Subscribe(ch) }, feed.Send)
+}
@@ -0,0 +1,137 @@
+package event
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// FeedMetrics receives delivery measurements from a feed, e.g. to export them to a
+// monitoring system. The methods are called from Send and must be fast.
+type FeedMetrics interface {
+	// ObserveSend is called after every Send with the number of subscribers that
+	// received the value and the time Send took.
+	ObserveSend(nsent int, elapsed time.Duration)
+	// ObserveBlocked is called when Send had to wait for sub, with the time it waited.
+	ObserveBlocked(sub Subscription, wait time.Duration)
+}
+
+// FeedStats is a snapshot of the subscriptions of a feed and of its delivery counters.
+type FeedStats struct {
+	Inbox       int // blocking subscriptions added since the last Send
+	Active      int // blocking subscriptions in the set used by Send
+	NonBlocking int // subscriptions with a non-blocking DeliveryPolicy
+
+	Sends         uint64    // completed Send calls
+	BlockedSends  uint64    // Send calls which waited for at least one subscriber
+	SendLatency   Histogram // duration of Send calls
+	Subscriptions []SubscriptionStats
+}
+
+// Subscribers returns the total number of subscriptions.
+func (s FeedStats) Subscribers() int {
+	return s.Inbox + s.Active + s.NonBlocking
+}
+
+// SubscriptionStats describes a single subscription of a feed.
+type SubscriptionStats struct {
+	Subscription Subscription
+	Policy       DeliveryPolicy
+	QueueLen     int       // values buffered in the channel and the DropOldest ring
+	QueueCap     int       // capacity of the channel and the DropOldest ring
+	Blocked      uint64    // Send calls which waited for this subscription
+	Wait         Histogram // time Send spent waiting for this subscription
+}
+
+// latencyBounds are the upper bounds of the Histogram buckets.
+var latencyBounds = [...]time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram counts durations in buckets. Counts[i] is the number of durations up to
+// Bounds[i], the last element of Counts holds those longer than every bound.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+// Mean returns the average duration.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// histogram is the lock free accumulator behind Histogram.
+type histogram struct {
+	counts [len(latencyBounds) + 1]atomic.Uint64
+	sum    atomic.Int64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(latencyBounds) && d > latencyBounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{Bounds: append([]time.Duration(nil), latencyBounds[:]...), Counts: make([]uint64, len(h.counts))}
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+		s.Count += s.Counts[i]
+	}
+	s.Sum = time.Duration(h.sum.Load())
+	return s
+}
+
+// feedCounters accumulates the delivery statistics of a feed.
+type feedCounters struct {
+	sends   atomic.Uint64
+	blocked atomic.Uint64
+	latency histogram
+}
+
+// sendDone records a finished Send.
+func (c *feedCounters) sendDone(metrics FeedMetrics, nsent int, start time.Time, blocked bool) {
+	elapsed := time.Since(start)
+	c.sends.Add(1)
+	if blocked {
+		c.blocked.Add(1)
+	}
+	c.latency.observe(elapsed)
+	if metrics != nil {
+		metrics.ObserveSend(nsent, elapsed)
+	}
+}
+
+func (c *feedCounters) fill(stats *FeedStats) {
+	stats.Sends = c.sends.Load()
+	stats.BlockedSends = c.blocked.Load()
+	stats.SendLatency = c.latency.snapshot()
+}
+
+// subCounters accumulates the delivery statistics of a subscription.
+type subCounters struct {
+	blocked atomic.Uint64
+	wait    histogram
+}
+
+// waited records that Send waited for sub since blockStart.
+func (c *subCounters) waited(metrics FeedMetrics, sub Subscription, blockStart time.Time) {
+	wait := time.Since(blockStart)
+	c.blocked.Add(1)
+	c.wait.observe(wait)
+	if metrics != nil {
+		metrics.ObserveBlocked(sub, wait)
+	}
+}
@@ -0,0 +1,153 @@
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingMetrics struct {
+	mu      sync.Mutex
+	sends   []int
+	blocked map[Subscription]time.Duration
+}
+
+func (m *recordingMetrics) ObserveSend(nsent int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sends = append(m.sends, nsent)
+}
+
+func (m *recordingMetrics) ObserveBlocked(sub Subscription, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.blocked == nil {
+		m.blocked = make(map[Subscription]time.Duration)
+	}
+	m.blocked[sub] += wait
+}
+
+func findSubStats(t *testing.T, stats FeedStats, sub Subscription) SubscriptionStats {
+	t.Helper()
+	for _, s := range stats.Subscriptions {
+		if s.Subscription == sub {
+			return s
+		}
+	}
+	t.Fatalf("subscription missing from stats")
+	return SubscriptionStats{}
+}
+
+func TestFeedStats(t *testing.T) {
+	var (
+		feed    Feed
+		metrics recordingMetrics
+		slowCh  = make(chan int)
+		fastCh  = make(chan int, 4)
+		dropCh  = make(chan int, 1)
+	)
+	feed.SetMetrics(&metrics)
+	slow := feed.Subscribe(slowCh)
+	defer slow.Unsubscribe()
+	fast := feed.Subscribe(fastCh)
+	defer fast.Unsubscribe()
+	drop := feed.Subscribe(dropCh, WithPolicy(DropOldest), WithRingSize(8))
+	defer drop.Unsubscribe()
+
+	stats := feed.Stats()
+	if stats.Inbox != 2 || stats.Active != 0 || stats.NonBlocking != 1 || stats.Subscribers() != 3 {
+		t.Fatalf("before Send: inbox %d active %d nonblocking %d", stats.Inbox, stats.Active, stats.NonBlocking)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		feed.Send(1)
+		close(done)
+	}()
+	// Send waits for the slow subscriber, which is visible in Stats meanwhile.
+	waitFor(t, func() bool { return feed.Stats().Inbox == 0 })
+	const delay = 20 * time.Millisecond
+	time.Sleep(delay)
+	if s := findSubStats(t, feed.Stats(), fast); s.QueueLen != 1 || s.QueueCap != 4 {
+		t.Errorf("fast subscriber queue %d/%d, want 1/4", s.QueueLen, s.QueueCap)
+	}
+	<-slowCh
+	<-done
+
+	stats = feed.Stats()
+	if stats.Active != 2 || stats.Sends != 1 || stats.BlockedSends != 1 {
+		t.Errorf("after Send: active %d sends %d blocked sends %d", stats.Active, stats.Sends, stats.BlockedSends)
+	}
+	if stats.SendLatency.Count != 1 || stats.SendLatency.Sum < delay {
+		t.Errorf("send latency count %d sum %v", stats.SendLatency.Count, stats.SendLatency.Sum)
+	}
+	if s := findSubStats(t, stats, slow); s.Blocked != 1 || s.Wait.Sum < delay {
+		t.Errorf("slow subscriber blocked %d times for %v", s.Blocked, s.Wait.Sum)
+	}
+	if s := findSubStats(t, stats, fast); s.Blocked != 0 {
+		t.Errorf("fast subscriber blocked %d times", s.Blocked)
+	}
+	if s := findSubStats(t, stats, drop); s.Policy != DropOldest || s.QueueCap != 9 {
+		t.Errorf("drop subscriber policy %v queue cap %d", s.Policy, s.QueueCap)
+	}
+
+	metrics.mu.Lock()
+	if len(metrics.sends) != 1 || metrics.sends[0] != 3 {
+		t.Errorf("hook observed sends %v, want [3]", metrics.sends)
+	}
+	if len(metrics.blocked) != 1 || metrics.blocked[slow] < delay {
+		t.Errorf("hook observed blocked subscribers %v", metrics.blocked)
+	}
+	metrics.mu.Unlock()
+
+	slow.Unsubscribe()
+	if n := feed.Stats().Subscribers(); n != 2 {
+		t.Errorf("%d subscribers after Unsubscribe, want 2", n)
+	}
+}
+
+func TestFeedOfStats(t *testing.T) {
+	var (
+		feed   FeedOf[int]
+		slowCh = make(chan int)
+		fastCh = make(chan int, 4)
+	)
+	slow := feed.Subscribe(slowCh)
+	defer slow.Unsubscribe()
+	fast := feed.Subscribe(fastCh)
+	defer fast.Unsubscribe()
+	disc := feed.Subscribe(make(chan int), WithPolicy(Disconnect))
+	defer disc.Unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		feed.Send(1)
+		close(done)
+	}()
+	const delay = 20 * time.Millisecond
+	time.Sleep(delay)
+	<-slowCh
+	<-done
+
+	stats := feed.Stats()
+	// The Disconnect subscriber was dropped by Send.
+	if stats.Active != 2 || stats.NonBlocking != 0 || stats.Subscribers() != 2 {
+		t.Errorf("active %d nonblocking %d", stats.Active, stats.NonBlocking)
+	}
+	if stats.Sends != 1 || stats.BlockedSends != 1 {
+		t.Errorf("sends %d blocked sends %d", stats.Sends, stats.BlockedSends)
+	}
+	if s := findSubStats(t, stats, slow); s.Blocked != 1 || s.Wait.Mean() < delay {
+		t.Errorf("slow subscriber blocked %d times for %v", s.Blocked, s.Wait.Sum)
+	}
+	if s := findSubStats(t, stats, fast); s.Blocked != 0 || s.QueueLen != 1 {
+		t.Errorf("fast subscriber blocked %d times, queue len %d", s.Blocked, s.QueueLen)
+	}
+
+	<-fastCh
+	go func() { <-slowCh }()
+	feed.Send(2)
+	if stats = feed.Stats(); stats.Sends != 2 || stats.SendLatency.Count != 2 {
+		t.Errorf("sends %d latency count %d", stats.Sends, stats.SendLatency.Count)
+	}
+}
@@ -0,0 +1,65 @@
+package event
+
+import "reflect"
+
+// TypeMux dispatches events of any type to subscribers by the dynamic type of the
+// event. Unlike Feed, it never panics on a type mismatch: an event is delivered to
+// the subscribers of its dynamic type and to those of every interface type it
+// implements, and to nobody if there are none. Subscribe to interface{} to receive
+// all events.
+//
+// Each subscribed type is backed by a FeedOf, which is torn down when its last
+// subscriber unsubscribes. The zero value is ready to use.
+type TypeMux struct {
+	reg    feedRegistry[reflect.Type]                   // feeds of the subscribed types, reg.mu protects the TypeMux
+	routes map[reflect.Type][]*sharedFeed[reflect.Type] // feeds receiving each posted type, reset when feeds change
+}
+
+// muxAccepts reports whether events of type t are delivered to subscribers of etype.
+func muxAccepts(etype, t reflect.Type) bool {
+	return etype == t || (etype.Kind() == reflect.Interface && t.Implements(etype))
+}
+
+// Subscribe adds a channel receiving the events posted on mux whose dynamic type is
+// T or, if T is an interface type, implements T.
+func Subscribe[T any](mux *TypeMux, channel chan<- T, opts ...SubscribeOption) Subscription {
+	mux.reg.mu.Lock()
+	defer mux.reg.mu.Unlock()
+	if mux.reg.teardown == nil {
+		mux.reg.teardown = func(*sharedFeed[reflect.Type]) { mux.routes = nil }
+	}
+	sub, _, created := subscribeShared(&mux.reg, reflect.TypeFor[T](), channel, opts)
+	if created {
+		mux.routes = nil
+	}
+	return sub
+}
+
+// Post delivers ev to the subscribers of its type. It returns the number of
+// subscribers the event was sent to, which is zero for a nil event.
+func (mux *TypeMux) Post(ev interface{}) (nsent int) {
+	if ev == nil {
+		return 0
+	}
+	t := reflect.TypeOf(ev)
+
+	mux.reg.mu.Lock()
+	feeds, ok := mux.routes[t]
+	if !ok {
+		for etype, sf := range mux.reg.feeds {
+			if muxAccepts(etype, t) {
+				feeds = append(feeds, sf)
+			}
+		}
+		if mux.routes == nil {
+			mux.routes = make(map[reflect.Type][]*sharedFeed[reflect.Type])
+		}
+		mux.routes[t] = feeds
+	}
+	mux.reg.mu.Unlock()
+
+	for _, sf := range feeds {
+		nsent += sf.send(ev)
+	}
+	return nsent
+}
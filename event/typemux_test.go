@@ -0,0 +1,89 @@
+package event
+
+import (
+	"fmt"
+	"testing"
+)
+
+type muxBlock struct{ Number int }
+
+func (b muxBlock) String() string { return fmt.Sprintf("block %d", b.Number) }
+
+type muxTx struct{ Hash string }
+
+func (tx *muxTx) String() string { return "tx " + tx.Hash }
+
+func TestTypeMux(t *testing.T) {
+	var (
+		mux      TypeMux
+		blocks   = make(chan muxBlock, 10)
+		txs      = make(chan *muxTx, 10)
+		stringer = make(chan fmt.Stringer, 10)
+		all      = make(chan interface{}, 10)
+	)
+	defer Subscribe(&mux, blocks).Unsubscribe()
+	defer Subscribe(&mux, txs).Unsubscribe()
+	defer Subscribe(&mux, stringer).Unsubscribe()
+	defer Subscribe(&mux, all).Unsubscribe()
+
+	if n := mux.Post(muxBlock{1}); n != 3 {
+		t.Errorf("Post(block) delivered %d times, want 3", n)
+	}
+	if n := mux.Post(&muxTx{"0xab"}); n != 3 {
+		t.Errorf("Post(tx) delivered %d times, want 3", n)
+	}
+	// Types without a dedicated subscriber do not panic.
+	if n := mux.Post(42); n != 1 {
+		t.Errorf("Post(int) delivered %d times, want 1", n)
+	}
+	if n := mux.Post(muxTx{"0xcd"}); n != 1 {
+		t.Errorf("Post(non-pointer tx) delivered %d times, want 1", n)
+	}
+	if n := mux.Post(nil); n != 0 {
+		t.Errorf("Post(nil) delivered %d times, want 0", n)
+	}
+
+	expectRecv(t, blocks, muxBlock{1})
+	if tx := <-txs; tx.Hash != "0xab" {
+		t.Errorf("received tx %s, want 0xab", tx.Hash)
+	}
+	for _, want := range []string{"block 1", "tx 0xab"} {
+		if s := (<-stringer).String(); s != want {
+			t.Errorf("Stringer subscriber received %q, want %q", s, want)
+		}
+	}
+	if len(all) != 4 {
+		t.Errorf("interface{} subscriber received %d events, want 4", len(all))
+	}
+}
+
+func TestTypeMuxUnsubscribe(t *testing.T) {
+	var (
+		mux    TypeMux
+		blocks = make(chan muxBlock, 10)
+	)
+	sub1 := Subscribe(&mux, blocks)
+	sub2 := Subscribe(&mux, blocks)
+	if n := mux.Post(muxBlock{1}); n != 2 {
+		t.Errorf("Post delivered %d times, want 2", n)
+	}
+	sub1.Unsubscribe()
+	sub1.Unsubscribe()
+	if n := mux.Post(muxBlock{2}); n != 1 {
+		t.Errorf("Post delivered %d times, want 1", n)
+	}
+	sub2.Unsubscribe()
+	if n := mux.Post(muxBlock{3}); n != 0 {
+		t.Errorf("Post delivered %d times after Unsubscribe, want 0", n)
+	}
+	if len(mux.reg.feeds) != 0 {
+		t.Errorf("%d feeds left after the last Unsubscribe", len(mux.reg.feeds))
+	}
+
+	// A new subscriber after the teardown is routed again.
+	sub3 := Subscribe(&mux, blocks)
+	defer sub3.Unsubscribe()
+	if n := mux.Post(muxBlock{4}); n != 1 {
+		t.Errorf("Post delivered %d times to the new subscriber, want 1", n)
+	}
+}
@@ -1,6 +1,6 @@
 module go-common-utils
 
-go 1.22
+go 1.23
 
 require (
 	github.com/ethereum/go-ethereum v1.14.8
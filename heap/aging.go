@@ -0,0 +1,87 @@
+package heap
+
+import "time"
+
+// AgingQueue 带老化机制的优先队列，数值越大优先级越高
+//
+// 元素的有效优先级 = 初始优先级 + rate * 等待秒数，等待越久越靠前，避免低优先级任务饿死；
+// 有效优先级相同时按放入顺序先进先出。
+// 由于所有元素老化速度相同，排序只取决于 初始优先级 - rate * 放入时间，因此无需随时间重建堆。
+// 与 Heap 一样，AgingQueue 不是并发安全的
+type AgingQueue[V any] struct {
+	now   func() time.Time
+	base  time.Time
+	rate  float64
+	seq   uint64
+	items Heap[*agingItem[V]]
+}
+
+type agingItem[V any] struct {
+	value    V
+	priority float64
+	enqueued time.Time
+	key      float64 // priority - rate * 放入时距 base 的秒数
+	seq      uint64
+}
+
+func (a *agingItem[V]) Less(t Element) bool {
+	b := t.(*agingItem[V])
+	if a.key == b.key {
+		return a.seq < b.seq
+	}
+	return a.key > b.key
+}
+
+func (a *agingItem[V]) IsUsable() bool {
+	return true
+}
+
+// NewAgingQueue 创建老化速度为每秒 rate 的优先队列，now 为 nil 时使用 time.Now
+func NewAgingQueue[V any](rate float64, now func() time.Time) *AgingQueue[V] {
+	if now == nil {
+		now = time.Now
+	}
+	q := &AgingQueue[V]{now: now, base: now(), rate: rate}
+	q.items.Init()
+	return q
+}
+
+// Push 以初始优先级 priority 放入一个元素
+func (q *AgingQueue[V]) Push(value V, priority float64) {
+	t := q.now()
+	q.seq++
+	q.items.PushOne(&agingItem[V]{
+		value:    value,
+		priority: priority,
+		enqueued: t,
+		key:      priority - q.rate*t.Sub(q.base).Seconds(),
+		seq:      q.seq,
+	})
+}
+
+// Pop 取出当前有效优先级最高的元素
+func (q *AgingQueue[V]) Pop() (V, bool) {
+	item, ok := q.items.PopOne()
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return item.value, true
+}
+
+// Peek 返回当前有效优先级最高的元素及其有效优先级，不取出
+func (q *AgingQueue[V]) Peek() (value V, priority float64, ok bool) {
+	item, ok := q.items.Peek()
+	if !ok {
+		return value, 0, false
+	}
+	return item.value, q.effective(item), true
+}
+
+func (q *AgingQueue[V]) Len() int {
+	return q.items.Len()
+}
+
+func (q *AgingQueue[V]) effective(item *agingItem[V]) float64 {
+	return item.priority + q.rate*q.now().Sub(item.enqueued).Seconds()
+}
@@ -0,0 +1,77 @@
+package heap
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestAgingQueueOrder(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	q := NewAgingQueue[string](0, clock.now)
+	q.Push("low", 1)
+	q.Push("high", 10)
+	q.Push("mid", 5)
+	for _, want := range []string{"high", "mid", "low"} {
+		if v, ok := q.Pop(); !ok || v != want {
+			t.Fatalf("Pop = %q, want %q", v, want)
+		}
+	}
+	if _, ok := q.Pop(); ok {
+		t.Errorf("Pop on empty queue succeeded")
+	}
+}
+
+func TestAgingQueueTieBreak(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	q := NewAgingQueue[int](1, clock.now)
+	for i := 0; i < 10; i++ {
+		q.Push(i, 3)
+	}
+	for want := 0; want < 10; want++ {
+		if v, _ := q.Pop(); v != want {
+			t.Fatalf("Pop = %d, want %d", v, want)
+		}
+	}
+}
+
+// Checks that a low-priority job overtakes newer high-priority jobs once it
+// has waited long enough.
+func TestAgingQueueNoStarvation(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	q := NewAgingQueue[string](1, clock.now)
+	q.Push("low", 0)
+
+	// Sustained high-priority load: one job per second at priority 5.
+	for i := 0; i < 4; i++ {
+		clock.advance(time.Second)
+		q.Push("high", 5)
+		if v, _ := q.Pop(); v != "high" {
+			t.Fatalf("second %d: Pop = %q, want high", i+1, v)
+		}
+	}
+	// After waiting 5s the low job ties with the new one and wins by
+	// insertion order.
+	clock.advance(time.Second)
+	q.Push("high", 5)
+	if v, p, _ := q.Peek(); v != "low" || p != 5 {
+		t.Fatalf("Peek = %q (%v), want low (5)", v, p)
+	}
+	if v, _ := q.Pop(); v != "low" {
+		t.Fatalf("Pop = %q, want low", v)
+	}
+	if q.Len() != 1 {
+		t.Errorf("len = %d, want 1", q.Len())
+	}
+}
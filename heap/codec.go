@@ -0,0 +1,46 @@
+package heap
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// 堆的序列化只保存元素本身，元素通过各自的 json/gob 编解码方式处理。
+// 反序列化时不信任数据中的元素顺序，总是重新建堆
+
+func (h Heap[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]T(h))
+}
+
+func (h *Heap[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	h.restore(items)
+	return nil
+}
+
+func (h Heap[T]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode([]T(h)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *Heap[T]) GobDecode(data []byte) error {
+	var items []T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&items); err != nil {
+		return err
+	}
+	h.restore(items)
+	return nil
+}
+
+func (h *Heap[T]) restore(items []T) {
+	*h = items
+	heap.Init(h)
+}
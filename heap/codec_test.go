@@ -0,0 +1,54 @@
+package heap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+func TestHeapJSON(t *testing.T) {
+	h, values := newRandomHeap(100)
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Heap[testElem]
+	if err = json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	checkInvariant(t, restored)
+	for _, want := range values {
+		if v, _ := restored.PopOne(); int(v) != want {
+			t.Fatalf("PopOne = %d, want %d", v, want)
+		}
+	}
+}
+
+// Checks that decoding rebuilds the heap instead of trusting the stored layout.
+func TestHeapJSONUntrustedLayout(t *testing.T) {
+	var h Heap[testElem]
+	if err := json.Unmarshal([]byte("[9, 3, 7, 1, 5]"), &h); err != nil {
+		t.Fatal(err)
+	}
+	checkInvariant(t, h)
+	if v, _ := h.Peek(); v != 1 {
+		t.Errorf("Peek = %d, want 1", v)
+	}
+}
+
+func TestHeapGob(t *testing.T) {
+	h, values := newRandomHeap(100)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(h); err != nil {
+		t.Fatal(err)
+	}
+	var restored Heap[testElem]
+	if err := gob.NewDecoder(&buf).Decode(&restored); err != nil {
+		t.Fatal(err)
+	}
+	checkInvariant(t, restored)
+	if got := restored.Sorted(); len(got) != len(values) || int(got[0]) != values[0] {
+		t.Fatalf("restored %d elements starting at %d", len(got), got[0])
+	}
+}
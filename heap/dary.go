@@ -0,0 +1,101 @@
+package heap
+
+// DaryHeap d 叉数组堆，d 越大树越矮，PushOne 越快而 PopOne 需要比较的子节点越多
+// 零值即可使用，此时为二叉堆
+type DaryHeap[T Element] struct {
+	d     int
+	items []T
+}
+
+// NewDaryHeap 创建 d 叉堆，d 必须不小于 2
+func NewDaryHeap[T Element](d int) *DaryHeap[T] {
+	if d < 2 {
+		panic("heap: DaryHeap arity must be at least 2")
+	}
+	return &DaryHeap[T]{d: d}
+}
+
+// arity 返回叉数，零值时为 2
+func (h *DaryHeap[T]) arity() int {
+	if h.d == 0 {
+		return 2
+	}
+	return h.d
+}
+
+func (h *DaryHeap[T]) PushOne(one T) {
+	h.items = append(h.items, one)
+	h.up(len(h.items) - 1)
+}
+
+func (h *DaryHeap[T]) PopOne() (T, bool) {
+	if len(h.items) == 0 || !h.items[0].IsUsable() {
+		var zero T
+		return zero, false
+	}
+	top := h.items[0]
+	n := len(h.items) - 1
+	h.items[0] = h.items[n]
+	var zero T
+	h.items[n] = zero
+	h.items = h.items[:n]
+	h.down(0)
+	return top, true
+}
+
+func (h *DaryHeap[T]) Peek() (T, bool) {
+	if len(h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return h.items[0], true
+}
+
+// Merge 将 other 中的全部元素并入 h 并清空 other
+// other 与 h 为同一个堆时不做任何操作
+func (h *DaryHeap[T]) Merge(other *DaryHeap[T]) {
+	if other == h {
+		return
+	}
+	h.items = append(h.items, other.items...)
+	other.items = nil
+	for i := (len(h.items) - 2) / h.arity(); i >= 0; i-- {
+		h.down(i)
+	}
+}
+
+func (h *DaryHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *DaryHeap[T]) up(i int) {
+	for i > 0 {
+		p := (i - 1) / h.arity()
+		if !h.items[i].Less(h.items[p]) {
+			return
+		}
+		h.items[i], h.items[p] = h.items[p], h.items[i]
+		i = p
+	}
+}
+
+func (h *DaryHeap[T]) down(i int) {
+	n, d := len(h.items), h.arity()
+	for {
+		first := d*i + 1
+		if first >= n {
+			return
+		}
+		m := first
+		for c := first + 1; c < first+d && c < n; c++ {
+			if h.items[c].Less(h.items[m]) {
+				m = c
+			}
+		}
+		if !h.items[m].Less(h.items[i]) {
+			return
+		}
+		h.items[i], h.items[m] = h.items[m], h.items[i]
+		i = m
+	}
+}
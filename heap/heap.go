@@ -1,6 +1,9 @@
 package heap
 
-import "container/heap"
+import (
+	"container/heap"
+	"iter"
+)
 
 // Element 自定义 Element 接口，支持自定义排序规则和可用规则
 type Element interface {
@@ -8,6 +11,14 @@ type Element interface {
 	IsUsable() bool
 }
 
+// Interface 各种堆实现的公共接口，Heap、DaryHeap 与 PairingHeap 均实现了该接口
+type Interface[T Element] interface {
+	PushOne(one T)
+	PopOne() (T, bool)
+	Peek() (T, bool)
+	Len() int
+}
+
 type Heap[T Element] []T
 
 func (h *Heap[T]) Init() {
@@ -33,6 +44,60 @@ func (h *Heap[T]) PopOne() (T, bool) {
 	return zero, false
 }
 
+// Peek 返回堆顶元素但不取出，不检查是否可用
+func (h *Heap[T]) Peek() (T, bool) {
+	if h.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	return (*h)[0], true
+}
+
+// Sorted 按排序规则返回全部元素的快照，不改变堆本身
+func (h *Heap[T]) Sorted() []T {
+	out := make([]T, 0, h.Len())
+	for one := range h.All() {
+		out = append(out, one)
+	}
+	return out
+}
+
+// All 按排序规则依次遍历全部元素，遍历基于副本进行，不改变堆本身
+func (h *Heap[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		c := h.Clone()
+		for c.Len() > 0 {
+			if !yield(heap.Pop(c).(T)) {
+				return
+			}
+		}
+	}
+}
+
+// Merge 将 other 中的全部元素并入 h 并清空 other，需要对合并结果重新建堆
+// other 与 h 为同一个堆时不做任何操作
+func (h *Heap[T]) Merge(other *Heap[T]) {
+	if other == h {
+		return
+	}
+	*h = append(*h, *other...)
+	other.Clear()
+	heap.Init(h)
+}
+
+// Clear 清空堆，保留底层数组以便复用
+func (h *Heap[T]) Clear() {
+	clear(*h)
+	*h = (*h)[:0]
+}
+
+// Clone 返回堆的浅拷贝，元素本身不会被复制
+func (h *Heap[T]) Clone() *Heap[T] {
+	c := make(Heap[T], h.Len())
+	copy(c, *h)
+	return &c
+}
+
 func (h *Heap[T]) Len() int {
 	return len(*h)
 }
@@ -0,0 +1,333 @@
+package heap
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// checkInvariant verifies that no element ranks before its parent.
+func checkInvariant[T Element](t *testing.T, h Heap[T]) {
+	t.Helper()
+	for i := 1; i < len(h); i++ {
+		if p := (i - 1) / 2; h[i].Less(h[p]) {
+			t.Fatalf("heap invariant violated at index %d", i)
+		}
+	}
+}
+
+func newRandomHeap(n int) (*Heap[testElem], []int) {
+	values := rand.Perm(n)
+	h := &Heap[testElem]{}
+	h.Init()
+	for _, v := range values {
+		h.PushOne(testElem(v))
+	}
+	sort.Ints(values)
+	return h, values
+}
+
+func TestHeapPeek(t *testing.T) {
+	h := &Heap[testElem]{}
+	if _, ok := h.Peek(); ok {
+		t.Errorf("Peek on empty heap succeeded")
+	}
+	h, values := newRandomHeap(100)
+	if v, ok := h.Peek(); !ok || int(v) != values[0] {
+		t.Errorf("Peek = %d, want %d", v, values[0])
+	}
+	if h.Len() != len(values) {
+		t.Errorf("Peek changed heap length to %d", h.Len())
+	}
+}
+
+func TestHeapSorted(t *testing.T) {
+	h, values := newRandomHeap(200)
+	got := h.Sorted()
+	for i := range values {
+		if int(got[i]) != values[i] {
+			t.Fatalf("index %d: got %d, want %d", i, got[i], values[i])
+		}
+	}
+	if h.Len() != len(values) {
+		t.Errorf("Sorted changed heap length to %d", h.Len())
+	}
+	checkInvariant(t, *h)
+}
+
+func TestHeapAll(t *testing.T) {
+	h, values := newRandomHeap(200)
+	i := 0
+	for one := range h.All() {
+		if int(one) != values[i] {
+			t.Fatalf("index %d: got %d, want %d", i, one, values[i])
+		}
+		if i++; i == 50 {
+			break
+		}
+	}
+	checkInvariant(t, *h)
+	if v, _ := h.PopOne(); int(v) != values[0] {
+		t.Errorf("PopOne after All = %d, want %d", v, values[0])
+	}
+}
+
+func TestHeapCloneClear(t *testing.T) {
+	h, values := newRandomHeap(100)
+	c := h.Clone()
+	c.PushOne(-1)
+	checkInvariant(t, *c)
+	if h.Len() != len(values) {
+		t.Errorf("push on clone changed original length to %d", h.Len())
+	}
+	if v, _ := h.Peek(); int(v) != values[0] {
+		t.Errorf("push on clone changed original top to %d", v)
+	}
+
+	h.Clear()
+	if h.Len() != 0 {
+		t.Errorf("len = %d after Clear", h.Len())
+	}
+	if c.Len() != len(values)+1 {
+		t.Errorf("Clear changed clone length to %d", c.Len())
+	}
+	h.PushOne(7)
+	if v, _ := h.PopOne(); v != 7 {
+		t.Errorf("PopOne after Clear = %d, want 7", v)
+	}
+}
+
+var (
+	_ Interface[testElem] = (*Heap[testElem])(nil)
+	_ Interface[testElem] = (*DaryHeap[testElem])(nil)
+	_ Interface[testElem] = (*PairingHeap[testElem])(nil)
+)
+
+// implementations lists a constructor for every heap implementing Interface.
+var implementations = []struct {
+	name string
+	new  func() Interface[testElem]
+}{
+	{"binary", func() Interface[testElem] { return &Heap[testElem]{} }},
+	{"4-ary", func() Interface[testElem] { return NewDaryHeap[testElem](4) }},
+	{"8-ary", func() Interface[testElem] { return NewDaryHeap[testElem](8) }},
+	{"pairing", func() Interface[testElem] { return &PairingHeap[testElem]{} }},
+}
+
+func TestInterfacePushPop(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			h := impl.new()
+			if _, ok := h.PopOne(); ok {
+				t.Errorf("PopOne on empty heap succeeded")
+			}
+			values := rand.Perm(500)
+			for _, v := range values {
+				h.PushOne(testElem(v))
+			}
+			if h.Len() != len(values) {
+				t.Fatalf("len = %d, want %d", h.Len(), len(values))
+			}
+			for want := 0; want < len(values); want++ {
+				if v, _ := h.Peek(); int(v) != want {
+					t.Fatalf("Peek = %d, want %d", v, want)
+				}
+				if v, ok := h.PopOne(); !ok || int(v) != want {
+					t.Fatalf("PopOne = %d, want %d", v, want)
+				}
+			}
+			if h.Len() != 0 {
+				t.Errorf("len = %d after draining heap", h.Len())
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	const shards, perShard = 8, 100
+	fill := func(push func(testElem)) {
+		for i := 0; i < perShard; i++ {
+			push(testElem(rand.Intn(10000)))
+		}
+	}
+	drain := func(t *testing.T, h Interface[testElem]) {
+		t.Helper()
+		if h.Len() != shards*perShard {
+			t.Fatalf("len = %d, want %d", h.Len(), shards*perShard)
+		}
+		prev := testElem(-1)
+		for h.Len() > 0 {
+			v, _ := h.PopOne()
+			if v < prev {
+				t.Fatalf("popped %d after %d", v, prev)
+			}
+			prev = v
+		}
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		h := &Heap[testElem]{}
+		for i := 0; i < shards; i++ {
+			shard := &Heap[testElem]{}
+			fill(shard.PushOne)
+			h.Merge(shard)
+			if shard.Len() != 0 {
+				t.Fatalf("merged shard not emptied")
+			}
+		}
+		drain(t, h)
+	})
+	t.Run("d-ary", func(t *testing.T) {
+		h := NewDaryHeap[testElem](4)
+		for i := 0; i < shards; i++ {
+			shard := NewDaryHeap[testElem](4)
+			fill(shard.PushOne)
+			h.Merge(shard)
+			if shard.Len() != 0 {
+				t.Fatalf("merged shard not emptied")
+			}
+		}
+		drain(t, h)
+	})
+	t.Run("pairing", func(t *testing.T) {
+		h := &PairingHeap[testElem]{}
+		for i := 0; i < shards; i++ {
+			shard := &PairingHeap[testElem]{}
+			fill(shard.PushOne)
+			h.Merge(shard)
+			if shard.Len() != 0 {
+				t.Fatalf("merged shard not emptied")
+			}
+		}
+		drain(t, h)
+	})
+}
+
+func TestMergeSelf(t *testing.T) {
+	check := func(t *testing.T, h Interface[testElem], mergeSelf func()) {
+		t.Helper()
+		h.PushOne(2)
+		h.PushOne(1)
+		mergeSelf()
+		if h.Len() != 2 {
+			t.Fatalf("len = %d after merging with itself, want 2", h.Len())
+		}
+		for _, want := range []testElem{1, 2} {
+			if v, ok := h.PopOne(); !ok || v != want {
+				t.Fatalf("popped %d, %v, want %d", v, ok, want)
+			}
+		}
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		h := &Heap[testElem]{}
+		check(t, h, func() { h.Merge(h) })
+	})
+	t.Run("d-ary", func(t *testing.T) {
+		h := NewDaryHeap[testElem](4)
+		check(t, h, func() { h.Merge(h) })
+	})
+	t.Run("pairing", func(t *testing.T) {
+		h := &PairingHeap[testElem]{}
+		check(t, h, func() { h.Merge(h) })
+	})
+}
+
+func TestDaryHeapZero(t *testing.T) {
+	var h, other DaryHeap[testElem]
+	for _, v := range []testElem{5, 3, 4} {
+		h.PushOne(v)
+	}
+	for _, v := range []testElem{2, 1} {
+		other.PushOne(v)
+	}
+	h.Merge(&other)
+	for want := testElem(1); want <= 5; want++ {
+		if v, ok := h.PopOne(); !ok || v != want {
+			t.Fatalf("popped %d, %v, want %d", v, ok, want)
+		}
+	}
+}
+
+func BenchmarkPush(b *testing.B) {
+	for _, impl := range implementations {
+		b.Run(impl.name, func(b *testing.B) {
+			h := impl.new()
+			for i := 0; i < b.N; i++ {
+				h.PushOne(testElem(rand.Int()))
+			}
+		})
+	}
+}
+
+func BenchmarkPushPop(b *testing.B) {
+	for _, impl := range implementations {
+		b.Run(impl.name, func(b *testing.B) {
+			h := impl.new()
+			for i := 0; i < 1000; i++ {
+				h.PushOne(testElem(rand.Int()))
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h.PushOne(testElem(rand.Int()))
+				h.PopOne()
+			}
+		})
+	}
+}
+
+// BenchmarkMerge merges 16 shards of 1000 elements each into one heap.
+func BenchmarkMerge(b *testing.B) {
+	const shards, perShard = 16, 1000
+	b.Run("binary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			parts := make([]*Heap[testElem], shards)
+			for s := range parts {
+				parts[s] = &Heap[testElem]{}
+				for j := 0; j < perShard; j++ {
+					parts[s].PushOne(testElem(rand.Int()))
+				}
+			}
+			b.StartTimer()
+			h := &Heap[testElem]{}
+			for _, p := range parts {
+				h.Merge(p)
+			}
+		}
+	})
+	b.Run("4-ary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			parts := make([]*DaryHeap[testElem], shards)
+			for s := range parts {
+				parts[s] = NewDaryHeap[testElem](4)
+				for j := 0; j < perShard; j++ {
+					parts[s].PushOne(testElem(rand.Int()))
+				}
+			}
+			b.StartTimer()
+			h := NewDaryHeap[testElem](4)
+			for _, p := range parts {
+				h.Merge(p)
+			}
+		}
+	})
+	b.Run("pairing", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			parts := make([]*PairingHeap[testElem], shards)
+			for s := range parts {
+				parts[s] = &PairingHeap[testElem]{}
+				for j := 0; j < perShard; j++ {
+					parts[s].PushOne(testElem(rand.Int()))
+				}
+			}
+			b.StartTimer()
+			h := &PairingHeap[testElem]{}
+			for _, p := range parts {
+				h.Merge(p)
+			}
+		}
+	})
+}
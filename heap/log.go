@@ -0,0 +1,184 @@
+package heap
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	logOpPush = "push"
+	logOpPop  = "pop"
+)
+
+// logRecord 日志中的一行，push 记录携带元素，pop 记录只表示取出了堆顶
+type logRecord struct {
+	Op    string          `json:"op"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// LogHeap 追加写日志模式的堆，每次 PushOne/PopOne 都会以一行 JSON 追加到日志文件并落盘，
+// 重启后通过 OpenLogHeap 重放日志恢复堆，无需每次重写整个文件。
+//
+// 重放依赖 pop 总是取出同一个堆顶，因此元素的 Less 需要是严格全序（参考 Account 按地址打破平局）。
+// LogHeap 不是并发安全的
+type LogHeap[T Element] struct {
+	path string
+	file *os.File
+	heap Heap[T]
+}
+
+// OpenLogHeap 打开或创建 path 处的日志并重放其中的记录。
+// 崩溃时写了一半的最后一行会被丢弃并从文件中截断
+func OpenLogHeap[T Element](path string) (*LogHeap[T], error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &LogHeap[T]{path: path, file: file}
+	if err = l.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *LogHeap[T]) replay() error {
+	r := bufio.NewReader(l.file)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// 没有换行结尾的内容是未写完的记录
+			if len(data) > 0 {
+				if err = l.file.Truncate(offset); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(data))
+
+		var rec logRecord
+		if err = json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("heap: log %s line %d: %w", l.path, line, err)
+		}
+		switch rec.Op {
+		case logOpPush:
+			var one T
+			if err = json.Unmarshal(rec.Value, &one); err != nil {
+				return fmt.Errorf("heap: log %s line %d: %w", l.path, line, err)
+			}
+			heap.Push(&l.heap, one)
+		case logOpPop:
+			if l.heap.Len() == 0 {
+				return fmt.Errorf("heap: log %s line %d: pop from empty heap", l.path, line)
+			}
+			heap.Pop(&l.heap)
+		default:
+			return fmt.Errorf("heap: log %s line %d: unknown op %q", l.path, line, rec.Op)
+		}
+	}
+	_, err := l.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (l *LogHeap[T]) append(rec logRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err = l.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// PushOne 记录并放入一个元素，写日志失败时堆不会改变
+func (l *LogHeap[T]) PushOne(one T) error {
+	value, err := json.Marshal(one)
+	if err != nil {
+		return err
+	}
+	if err = l.append(logRecord{Op: logOpPush, Value: value}); err != nil {
+		return err
+	}
+	l.heap.PushOne(one)
+	return nil
+}
+
+// PopOne 取出可用的堆顶元素并记录，写日志失败时堆不会改变
+func (l *LogHeap[T]) PopOne() (T, bool, error) {
+	var zero T
+	top, ok := l.heap.Peek()
+	if !ok || !top.IsUsable() {
+		return zero, false, nil
+	}
+	if err := l.append(logRecord{Op: logOpPop}); err != nil {
+		return zero, false, err
+	}
+	top, _ = l.heap.PopOne()
+	return top, true, nil
+}
+
+func (l *LogHeap[T]) Peek() (T, bool) {
+	return l.heap.Peek()
+}
+
+func (l *LogHeap[T]) Len() int {
+	return l.heap.Len()
+}
+
+// Compact 用当前堆内的元素重写日志，丢弃已经被取出的历史记录。
+// 新日志先写入临时文件再原子替换，中途失败不会损坏原日志
+func (l *LogHeap[T]) Compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// 按堆内顺序写入，重放后得到与当前完全相同的布局
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, one := range l.heap {
+		value, err := json.Marshal(one)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		if err = enc.Encode(logRecord{Op: logOpPush, Value: value}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if _, err = tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = os.Rename(tmp.Name(), l.path); err != nil {
+		tmp.Close()
+		return err
+	}
+	l.file.Close()
+	l.file = tmp
+	_, err = l.file.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (l *LogHeap[T]) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,111 @@
+package heap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogHeapReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heap.log")
+	l, err := OpenLogHeap[testElem](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []testElem{5, 2, 8, 1, 9} {
+		if err = l.PushOne(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v, ok, err := l.PopOne(); err != nil || !ok || v != 1 {
+		t.Fatalf("PopOne = %d, %v, %v, want 1", v, ok, err)
+	}
+	l.Close()
+
+	l, err = OpenLogHeap[testElem](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.Len() != 4 {
+		t.Fatalf("len = %d after replay, want 4", l.Len())
+	}
+	checkInvariant(t, l.heap)
+	for _, want := range []testElem{2, 5, 8, 9} {
+		if v, _, _ := l.PopOne(); v != want {
+			t.Fatalf("PopOne = %d, want %d", v, want)
+		}
+	}
+}
+
+func TestLogHeapTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heap.log")
+	data := "{\"op\":\"push\",\"value\":3}\n{\"op\":\"push\",\"value\":1}\n{\"op\":\"po"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := OpenLogHeap[testElem](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Len() != 2 {
+		t.Fatalf("len = %d, want 2", l.Len())
+	}
+	// The torn record must not corrupt records appended after it.
+	if err = l.PushOne(2); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	l, err = OpenLogHeap[testElem](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.Len() != 3 {
+		t.Fatalf("len = %d after reopen, want 3", l.Len())
+	}
+}
+
+func TestLogHeapCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heap.log")
+	l, err := OpenLogHeap[testElem](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		l.PushOne(testElem(i))
+	}
+	for i := 0; i < 90; i++ {
+		l.PopOne()
+	}
+	before, _ := os.Stat(path)
+	if err = l.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Errorf("compacted log is %d bytes, was %d", after.Size(), before.Size())
+	}
+	// Appends after compaction go to the new file.
+	l.PushOne(-1)
+	l.Close()
+
+	l, err = OpenLogHeap[testElem](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.Len() != 11 {
+		t.Fatalf("len = %d after reopen, want 11", l.Len())
+	}
+	for _, want := range []testElem{-1, 90, 91} {
+		if v, _, _ := l.PopOne(); v != want {
+			t.Fatalf("PopOne = %d, want %d", v, want)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("compaction left %d files behind", len(entries)-1)
+	}
+}
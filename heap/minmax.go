@@ -0,0 +1,170 @@
+package heap
+
+import "math/bits"
+
+// MinMaxHeap 双端堆，可同时以 O(log n) 取出按 Less 排序的最小和最大元素
+//
+// 偶数层为最小层，奇数层为最大层。与 Heap 一样，PopMin/PopMax 只会取出可用的元素
+type MinMaxHeap[T Element] []T
+
+func (h *MinMaxHeap[T]) Init() {
+	for i := len(*h)/2 - 1; i >= 0; i-- {
+		h.down(i)
+	}
+}
+
+func (h *MinMaxHeap[T]) PushOne(one T) {
+	*h = append(*h, one)
+	h.up(len(*h) - 1)
+}
+
+func (h *MinMaxHeap[T]) PeekMin() (T, bool) {
+	if len(*h) == 0 {
+		var zero T
+		return zero, false
+	}
+	return (*h)[0], true
+}
+
+func (h *MinMaxHeap[T]) PeekMax() (T, bool) {
+	if len(*h) == 0 {
+		var zero T
+		return zero, false
+	}
+	return (*h)[h.maxIndex()], true
+}
+
+func (h *MinMaxHeap[T]) PopMin() (T, bool) {
+	if len(*h) == 0 || !(*h)[0].IsUsable() {
+		var zero T
+		return zero, false
+	}
+	return h.removeAt(0), true
+}
+
+func (h *MinMaxHeap[T]) PopMax() (T, bool) {
+	if len(*h) == 0 {
+		var zero T
+		return zero, false
+	}
+	i := h.maxIndex()
+	if !(*h)[i].IsUsable() {
+		var zero T
+		return zero, false
+	}
+	return h.removeAt(i), true
+}
+
+func (h *MinMaxHeap[T]) Len() int {
+	return len(*h)
+}
+
+func (h *MinMaxHeap[T]) less(i, j int) bool {
+	return (*h)[i].Less((*h)[j])
+}
+
+func (h *MinMaxHeap[T]) swap(i, j int) {
+	(*h)[i], (*h)[j] = (*h)[j], (*h)[i]
+}
+
+func (h *MinMaxHeap[T]) maxIndex() int {
+	switch n := len(*h); {
+	case n == 1:
+		return 0
+	case n == 2 || h.less(2, 1):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (h *MinMaxHeap[T]) removeAt(i int) T {
+	old := *h
+	n := len(old) - 1
+	x := old[i]
+	old[i] = old[n]
+	var zero T
+	old[n] = zero
+	*h = old[:n]
+	if i < n {
+		h.down(i)
+	}
+	return x
+}
+
+// isMinLevel 判断下标 i 是否位于最小层
+func isMinLevel(i int) bool {
+	return bits.Len(uint(i+1))%2 == 1
+}
+
+func (h *MinMaxHeap[T]) up(i int) {
+	if i == 0 {
+		return
+	}
+	p := (i - 1) / 2
+	if isMinLevel(i) {
+		if h.less(p, i) {
+			h.swap(i, p)
+			h.upWith(p, func(a, b int) bool { return h.less(b, a) })
+		} else {
+			h.upWith(i, h.less)
+		}
+	} else {
+		if h.less(i, p) {
+			h.swap(i, p)
+			h.upWith(p, h.less)
+		} else {
+			h.upWith(i, func(a, b int) bool { return h.less(b, a) })
+		}
+	}
+}
+
+// upWith 沿祖父节点向上调整，before(a, b) 表示 a 应当排在 b 的上方
+func (h *MinMaxHeap[T]) upWith(i int, before func(a, b int) bool) {
+	for i >= 3 {
+		gp := ((i-1)/2 - 1) / 2
+		if !before(i, gp) {
+			return
+		}
+		h.swap(i, gp)
+		i = gp
+	}
+}
+
+func (h *MinMaxHeap[T]) down(i int) {
+	if isMinLevel(i) {
+		h.downWith(i, h.less)
+	} else {
+		h.downWith(i, func(a, b int) bool { return h.less(b, a) })
+	}
+}
+
+// downWith 在子孙节点中寻找最应上移的元素并向下调整，before 含义同 upWith
+func (h *MinMaxHeap[T]) downWith(i int, before func(a, b int) bool) {
+	n := len(*h)
+	for {
+		first := 2*i + 1
+		if first >= n {
+			return
+		}
+		// 在子节点与孙节点中寻找最靠前的元素
+		m := first
+		for _, c := range [...]int{2*i + 2, 4*i + 3, 4*i + 4, 4*i + 5, 4*i + 6} {
+			if c < n && before(c, m) {
+				m = c
+			}
+		}
+		if !before(m, i) {
+			return
+		}
+		h.swap(m, i)
+		if m <= 2*i+2 {
+			// 直接子节点位于相反层，交换后无需继续
+			return
+		}
+		if p := (m - 1) / 2; before(p, m) {
+			h.swap(m, p)
+		}
+		i = m
+	}
+}
@@ -0,0 +1,78 @@
+package heap
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestMinMaxHeap(t *testing.T) {
+	h := MinMaxHeap[testElem]{}
+	if _, ok := h.PopMin(); ok {
+		t.Errorf("PopMin on empty heap succeeded")
+	}
+	if _, ok := h.PopMax(); ok {
+		t.Errorf("PopMax on empty heap succeeded")
+	}
+
+	values := rand.Perm(500)
+	for _, v := range values {
+		h.PushOne(testElem(v))
+	}
+	sort.Ints(values)
+
+	// Alternate between both ends, checking against the sorted values.
+	lo, hi := 0, len(values)-1
+	for i := 0; lo <= hi; i++ {
+		if i%2 == 0 {
+			if v, _ := h.PeekMin(); int(v) != values[lo] {
+				t.Fatalf("PeekMin = %d, want %d", v, values[lo])
+			}
+			if v, ok := h.PopMin(); !ok || int(v) != values[lo] {
+				t.Fatalf("PopMin = %d, want %d", v, values[lo])
+			}
+			lo++
+		} else {
+			if v, _ := h.PeekMax(); int(v) != values[hi] {
+				t.Fatalf("PeekMax = %d, want %d", v, values[hi])
+			}
+			if v, ok := h.PopMax(); !ok || int(v) != values[hi] {
+				t.Fatalf("PopMax = %d, want %d", v, values[hi])
+			}
+			hi--
+		}
+	}
+	if h.Len() != 0 {
+		t.Errorf("len = %d after draining heap", h.Len())
+	}
+}
+
+func TestMinMaxHeapInit(t *testing.T) {
+	values := rand.Perm(300)
+	h := make(MinMaxHeap[testElem], len(values))
+	for i, v := range values {
+		h[i] = testElem(v)
+	}
+	h.Init()
+	for want := len(values) - 1; want >= 0; want-- {
+		if v, _ := h.PopMax(); int(v) != want {
+			t.Fatalf("PopMax = %d, want %d", v, want)
+		}
+	}
+}
+
+func BenchmarkMinMaxHeapPushPop(b *testing.B) {
+	h := MinMaxHeap[testElem]{}
+	for i := 0; i < 1000; i++ {
+		h.PushOne(testElem(rand.Int()))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.PushOne(testElem(rand.Int()))
+		if i%2 == 0 {
+			h.PopMin()
+		} else {
+			h.PopMax()
+		}
+	}
+}
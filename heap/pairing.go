@@ -0,0 +1,97 @@
+package heap
+
+// PairingHeap 配对堆，PushOne 与 Merge 均为 O(1)，PopOne 均摊 O(log n)
+//
+// 适合频繁合并多个堆的场景，零值即可使用
+type PairingHeap[T Element] struct {
+	root *pairingNode[T]
+	size int
+}
+
+type pairingNode[T Element] struct {
+	value   T
+	child   *pairingNode[T] // 最左子节点
+	sibling *pairingNode[T] // 右侧兄弟节点
+}
+
+func (h *PairingHeap[T]) PushOne(one T) {
+	h.root = meld(h.root, &pairingNode[T]{value: one})
+	h.size++
+}
+
+func (h *PairingHeap[T]) PopOne() (T, bool) {
+	if h.root == nil || !h.root.value.IsUsable() {
+		var zero T
+		return zero, false
+	}
+	top := h.root.value
+	h.root = mergePairs(h.root.child)
+	h.size--
+	return top, true
+}
+
+func (h *PairingHeap[T]) Peek() (T, bool) {
+	if h.root == nil {
+		var zero T
+		return zero, false
+	}
+	return h.root.value, true
+}
+
+// Merge 将 other 中的全部元素并入 h 并清空 other
+// other 与 h 为同一个堆时不做任何操作
+func (h *PairingHeap[T]) Merge(other *PairingHeap[T]) {
+	if other == h {
+		return
+	}
+	h.root = meld(h.root, other.root)
+	h.size += other.size
+	other.root, other.size = nil, 0
+}
+
+func (h *PairingHeap[T]) Len() int {
+	return h.size
+}
+
+// meld 合并两棵树，根较大的树成为另一棵树根的最左子树
+func meld[T Element](a, b *pairingNode[T]) *pairingNode[T] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if b.value.Less(a.value) {
+		a, b = b, a
+	}
+	b.sibling = a.child
+	a.child = b
+	return a
+}
+
+// mergePairs 两趟合并子树：先从左到右两两合并，再从右到左依次合并
+func mergePairs[T Element](first *pairingNode[T]) *pairingNode[T] {
+	var pairs *pairingNode[T]
+	for first != nil {
+		a, b := first, first.sibling
+		if b == nil {
+			first = nil
+		} else {
+			first = b.sibling
+			b.sibling = nil
+		}
+		a.sibling = nil
+		m := meld(a, b)
+		// 借用 sibling 将合并结果逆序串起来
+		m.sibling = pairs
+		pairs = m
+	}
+	var root *pairingNode[T]
+	for pairs != nil {
+		next := pairs.sibling
+		pairs.sibling = nil
+		root = meld(root, pairs)
+		pairs = next
+	}
+	return root
+}
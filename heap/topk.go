@@ -0,0 +1,91 @@
+package heap
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// TopK 有界堆，只保留按 Less 排序最靠前的 k 个元素，溢出时淘汰排序最靠后的元素
+type TopK[T Element] struct {
+	k     int
+	items reverseHeap[T]
+}
+
+// NewTopK 创建容量为 k 的有界堆，k 必须大于 0
+func NewTopK[T Element](k int) *TopK[T] {
+	if k <= 0 {
+		panic("heap: TopK capacity must be positive")
+	}
+	return &TopK[T]{k: k, items: make(reverseHeap[T], 0, k)}
+}
+
+// PushOne 放入一个元素，若超出容量则返回被淘汰的元素（可能就是 one 本身）
+func (t *TopK[T]) PushOne(one T) (evicted T, ok bool) {
+	if len(t.items) < t.k {
+		heap.Push(&t.items, one)
+		return evicted, false
+	}
+	worst := t.items[0]
+	if !one.Less(worst) {
+		return one, true
+	}
+	t.items[0] = one
+	heap.Fix(&t.items, 0)
+	return worst, true
+}
+
+// Peek 返回当前保留元素中排序最靠后的那个，即下一个会被淘汰的元素
+func (t *TopK[T]) Peek() (T, bool) {
+	if len(t.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return t.items[0], true
+}
+
+// Sorted 按 Less 排序返回当前保留的全部元素，不改变堆本身
+func (t *TopK[T]) Sorted() []T {
+	out := make([]T, len(t.items))
+	copy(out, t.items)
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Less(out[j])
+	})
+	return out
+}
+
+func (t *TopK[T]) Len() int {
+	return len(t.items)
+}
+
+func (t *TopK[T]) Cap() int {
+	return t.k
+}
+
+// reverseHeap 堆顶为排序最靠后的元素
+type reverseHeap[T Element] []T
+
+func (h *reverseHeap[T]) Len() int {
+	return len(*h)
+}
+
+func (h *reverseHeap[T]) Less(i, j int) bool {
+	return (*h)[j].Less((*h)[i])
+}
+
+func (h *reverseHeap[T]) Swap(i, j int) {
+	(*h)[i], (*h)[j] = (*h)[j], (*h)[i]
+}
+
+func (h *reverseHeap[T]) Push(x interface{}) {
+	*h = append(*h, x.(T))
+}
+
+func (h *reverseHeap[T]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	var zero T
+	old[n-1] = zero
+	*h = old[0 : n-1]
+	return x
+}
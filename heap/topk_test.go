@@ -0,0 +1,76 @@
+package heap
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type testElem int
+
+func (e testElem) Less(t Element) bool {
+	return e < t.(testElem)
+}
+
+func (e testElem) IsUsable() bool {
+	return true
+}
+
+func TestTopK(t *testing.T) {
+	topk := NewTopK[testElem](3)
+	for _, v := range []testElem{5, 1, 9, 3, 7} {
+		topk.PushOne(v)
+	}
+	if topk.Len() != 3 {
+		t.Fatalf("len = %d, want 3", topk.Len())
+	}
+	if worst, _ := topk.Peek(); worst != 5 {
+		t.Errorf("peek = %d, want 5", worst)
+	}
+	got := topk.Sorted()
+	want := []testElem{1, 3, 5}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sorted = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTopKEvict(t *testing.T) {
+	topk := NewTopK[testElem](2)
+	if _, ok := topk.PushOne(4); ok {
+		t.Errorf("eviction below capacity")
+	}
+	topk.PushOne(2)
+	if evicted, ok := topk.PushOne(3); !ok || evicted != 4 {
+		t.Errorf("evicted %d, %v, want 4, true", evicted, ok)
+	}
+	// An element ranking after every kept element is rejected directly.
+	if evicted, ok := topk.PushOne(8); !ok || evicted != 8 {
+		t.Errorf("evicted %d, %v, want 8, true", evicted, ok)
+	}
+}
+
+func TestTopKRandom(t *testing.T) {
+	const k = 10
+	topk := NewTopK[testElem](k)
+	all := make([]int, 1000)
+	for i := range all {
+		all[i] = rand.Intn(10000)
+		topk.PushOne(testElem(all[i]))
+	}
+	sort.Ints(all)
+	got := topk.Sorted()
+	for i := 0; i < k; i++ {
+		if int(got[i]) != all[i] {
+			t.Fatalf("index %d: got %d, want %d", i, got[i], all[i])
+		}
+	}
+}
+
+func BenchmarkTopKPush(b *testing.B) {
+	topk := NewTopK[testElem](100)
+	for i := 0; i < b.N; i++ {
+		topk.PushOne(testElem(rand.Int()))
+	}
+}
@@ -42,6 +42,7 @@ func (ts *TaskService) NewServer(serverName string, handler MessageHandler, sche
 		ScheduledTaskGoroutineNum: len(scheduledTasks),
 		ScheduledTasks:            scheduledTasks,
 		ScheduledTaskExit:         []chan int{},
+		done:                      make(chan struct{}),
 	}
 
 	if server.Opts.mode == LastMsg {
@@ -0,0 +1,70 @@
+package taskservice
+
+import (
+	"context"
+
+	"go-common-utils/event"
+)
+
+// SubscribeFeed sends every event of feed to the server as a message for its
+// MsgHandler. The server must be started with Go first.
+//
+// The feed's channel is unbuffered, so once the server queue is full, Send on the feed
+// blocks until the handlers catch up. The subscription ends when the server is
+// stopped or Unsubscribe is called, and fails if a message cannot be queued.
+func SubscribeFeed[T any](server *Server, feed *event.FeedOf[T]) event.Subscription {
+	ch := make(chan T)
+	sub := feed.Subscribe(ch)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			// Stop waiting for room in the queue on Unsubscribe.
+			select {
+			case <-quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		for {
+			select {
+			case v := <-ch:
+				if err := server.SendMessage(ctx, v); err != nil {
+					select {
+					case <-quit:
+						return nil
+					case <-server.Done():
+						return nil
+					default:
+						return err
+					}
+				}
+			case err := <-sub.Err():
+				return err
+			case <-server.Done():
+				return nil
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// Result is a message handled by a server and the handler's response.
+type Result struct {
+	Message  interface{}
+	Response interface{}
+	Err      error
+}
+
+// PublishResults wraps handler so that the outcome of every message is sent on feed.
+// Handing the wrapped handler to NewServer makes a slow subscriber of feed hold up
+// the handler goroutines, which in turn fills the server queue.
+func PublishResults(feed *event.FeedOf[Result], handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, msg interface{}, num int) (resp interface{}, err error) {
+		resp, err = handler(ctx, msg, num)
+		feed.Send(Result{Message: msg, Response: resp, Err: err})
+		return resp, err
+	}
+}
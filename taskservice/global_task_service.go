@@ -36,6 +36,7 @@ func NewServer(serverName string, handler MessageHandler, scheduledTasks []Sched
 		ScheduledTaskGoroutineNum: len(scheduledTasks),
 		ScheduledTasks:            scheduledTasks,
 		ScheduledTaskExit:         []chan int{},
+		done:                      make(chan struct{}),
 	}
 
 	if server.Opts.mode == LastMsg {
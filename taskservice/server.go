@@ -48,6 +48,16 @@ func WithOptionLastMsg() Option {
 	}
 }
 
+func WithOptionCapacity(capacity int) Option {
+	return func(opt *Options) error {
+		if capacity <= 0 {
+			return fmt.Errorf("invalid queue capacity %d", capacity)
+		}
+		opt.capacity = capacity
+		return nil
+	}
+}
+
 func NewDefaultOptions() *Options {
 	return &Options{
 		capacity: 4096,
@@ -65,6 +75,7 @@ type Server struct {
 	ScheduledTaskGoroutineNum int
 	ScheduledTasks            []ScheduledTask
 	ScheduledTaskExit         []chan int
+	done                      chan struct{} // closed by Stop
 }
 
 func (server *Server) Go() {
@@ -141,12 +152,30 @@ func (server *Server) Stop() {
 		for i := 0; i < server.ScheduledTaskGoroutineNum; i++ {
 			server.ScheduledTaskExit[i] <- 1
 		}
+		if server.done != nil {
+			close(server.done)
+		}
 		close(server.Queue)
 		Servers.Delete(server.Name)
 		fmt.Println(server.Name, " Server stopped")
 	}
 }
 
+// Done returns a channel which is closed when the server is stopped.
+func (server *Server) Done() <-chan struct{} {
+	return server.done
+}
+
+// enqueue puts m into the queue, waiting for room until ctx is done.
+func (server *Server) enqueue(ctx context.Context, m *Message) error {
+	select {
+	case server.Queue <- m:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (server *Server) SendMessage(ctx context.Context, msg interface{}) (err error) {
 	if server.State == Stopped {
 		return fmt.Errorf("server is stopped")
@@ -164,8 +193,7 @@ func (server *Server) SendMessage(ctx context.Context, msg interface{}) (err err
 		ctx:     ctx,
 		sync:    false,
 	}
-	server.Queue <- m
-	return nil
+	return server.enqueue(ctx, m)
 }
 
 func (server *Server) SendMessageWithResult(ctx context.Context, msg interface{}) (resp interface{}, err error) {
@@ -185,7 +213,9 @@ func (server *Server) SendMessageWithResult(ctx context.Context, msg interface{}
 		ctx:     ctx,
 		sync:    true,
 	}
-	server.Queue <- m
+	if err = server.enqueue(ctx, m); err != nil {
+		return nil, err
+	}
 	select {
 	case resp = <-m.ch:
 		close(m.ch)
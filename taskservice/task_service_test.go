@@ -3,6 +3,7 @@ package taskservice
 import (
 	"context"
 	"github.com/stretchr/testify/assert"
+	"go-common-utils/event"
 	"testing"
 	"time"
 )
@@ -76,9 +77,108 @@ func TestNewSvr(t *testing.T) {
 		}})
 
 		s.Go()
-		ctx, _ := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
 		_, err := s.SendMessageWithResult(ctx, "1")
 		assert.NotNil(t, err)
 		s.Stop()
 	})
 }
+
+func TestEventBridge(t *testing.T) {
+
+	t.Run("subscribe-feed", func(t *testing.T) {
+		handled := make(chan interface{}, 10)
+		s, err := NewServer("subscribe-feed", func(ctx context.Context, msg interface{}, num int) (resp interface{}, err error) {
+			handled <- msg
+			return msg, nil
+		}, nil)
+		assert.Nil(t, err)
+		s.Go()
+
+		var feed event.FeedOf[int]
+		sub := SubscribeFeed(s, &feed)
+		total := 0
+		for i := 1; i <= 3; i++ {
+			assert.Equal(t, 1, feed.Send(i))
+		}
+		for i := 0; i < 3; i++ {
+			total += (<-handled).(int)
+		}
+		assert.Equal(t, 6, total)
+
+		// Stopping the server ends the subscription.
+		s.Stop()
+		select {
+		case err, ok := <-sub.Err():
+			assert.False(t, ok, "subscription failed with %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("subscription not ended by Stop")
+		}
+		assert.Equal(t, 0, feed.Send(4))
+		sub.Unsubscribe()
+	})
+
+	t.Run("backpressure", func(t *testing.T) {
+		gate := make(chan struct{})
+		s, err := NewServer("backpressure", func(ctx context.Context, msg interface{}, num int) (resp interface{}, err error) {
+			<-gate
+			return msg, nil
+		}, nil, WithOptionCapacity(2))
+		assert.Nil(t, err)
+		s.Go()
+		defer s.Stop()
+
+		var feed event.FeedOf[int]
+		sub := SubscribeFeed(s, &feed)
+
+		// Every handler holds a message, the queue is full and the subscription holds
+		// one more, then Send blocks.
+		want := s.MsgHandlerGoroutineNum + 2 + 1
+		for i := 0; i < want; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			nsent, _, err := feed.SendContext(ctx, i)
+			cancel()
+			assert.Nil(t, err)
+			assert.Equal(t, 1, nsent)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, missed, err := feed.SendContext(ctx, want)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Len(t, missed, 1)
+
+		// Unsubscribe does not wait for room in the queue.
+		done := make(chan struct{})
+		go func() {
+			sub.Unsubscribe()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Unsubscribe blocked on the full queue")
+		}
+		close(gate)
+	})
+
+	t.Run("publish-results", func(t *testing.T) {
+		var (
+			feed    event.FeedOf[Result]
+			results = make(chan Result, 1)
+		)
+		sub := feed.Subscribe(results)
+		defer sub.Unsubscribe()
+		s, err := NewServer("publish-results", PublishResults(&feed, func(ctx context.Context, msg interface{}, num int) (resp interface{}, err error) {
+			return msg.(int) * 2, nil
+		}), nil)
+		assert.Nil(t, err)
+		s.Go()
+		defer s.Stop()
+
+		resp, err := s.SendMessageWithResult(context.Background(), 21)
+		assert.Nil(t, err)
+		assert.Equal(t, 42, resp)
+		assert.Equal(t, Result{Message: 21, Response: 42}, <-results)
+	})
+}